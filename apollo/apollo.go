@@ -0,0 +1,342 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package apollo is the config client plugin of ctrip apollo
+package apollo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-chassis/foundation/httpclient"
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/go-chassis/go-chassis-config/pkg/util"
+	"github.com/go-chassis/go-chassis-config/serializers"
+	"github.com/go-mesh/openlogging"
+)
+
+const (
+	// Name of the Plugin
+	Name = "apollo"
+	//LabelCluster is the label of apollo cluster
+	LabelCluster = "cluster"
+	//LabelNamespace is the label of apollo namespaces, separated by comma.
+	//namespace listed first has higher priority when keys conflict
+	LabelNamespace = "namespace"
+	//ParamSecret is the key of access key secret in options params
+	ParamSecret = "secret"
+
+	defaultCluster   = "default"
+	defaultNamespace = "application"
+	configAPI        = "/configs/%s/%s/%s"
+	notificationAPI  = "/notifications/v2"
+	//apollo server holds a long polling request for 60 seconds
+	longPollTimeout = 90 * time.Second
+	retryInterval   = 5 * time.Second
+)
+
+// errors
+var (
+	ErrInvalidEP = errors.New("invalid endpoint")
+	ErrAppEmpty  = errors.New("app can not be empty")
+)
+
+// Client is apollo config client implementation
+type Client struct {
+	opts    config.Options
+	c       *httpclient.Requests
+	servers []string
+	secret  string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// meta locates apollo namespaces
+type meta struct {
+	AppID      string
+	Cluster    string
+	Namespaces []string
+}
+
+// NewClient create apollo config client
+func NewClient(options config.Options) (config.Client, error) {
+	if options.ServerURI == "" {
+		return nil, ErrInvalidEP
+	}
+	servers := make([]string, 0)
+	for _, s := range strings.Split(options.ServerURI, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.HasPrefix(s, "http") {
+			if options.EnableSSL {
+				s = "https://" + s
+			} else {
+				s = "http://" + s
+			}
+		}
+		servers = append(servers, strings.TrimSuffix(s, "/"))
+	}
+	if len(servers) == 0 {
+		return nil, ErrInvalidEP
+	}
+	hc, err := httpclient.New(&httpclient.Options{
+		SSLEnabled:     options.EnableSSL,
+		TLSConfig:      options.TLSConfig,
+		RequestTimeout: longPollTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		opts:    options,
+		c:       hc,
+		servers: servers,
+		secret:  options.Params[ParamSecret],
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	openlogging.Info("new apollo client", openlogging.WithTags(
+		openlogging.Tags{
+			"ep":  servers,
+			"ssl": options.EnableSSL,
+		}))
+	return c, nil
+}
+
+func (c *Client) meta(labels map[string]string) (*meta, error) {
+	if len(labels) == 0 {
+		labels = c.opts.Labels
+	}
+	m := &meta{
+		AppID:   labels[config.LabelApp],
+		Cluster: labels[LabelCluster],
+	}
+	if m.AppID == "" {
+		return nil, ErrAppEmpty
+	}
+	if m.Cluster == "" {
+		m.Cluster = defaultCluster
+	}
+	for _, ns := range strings.Split(labels[LabelNamespace], ",") {
+		ns = strings.TrimSpace(ns)
+		if ns != "" {
+			m.Namespaces = append(m.Namespaces, normalize(ns))
+		}
+	}
+	if len(m.Namespaces) == 0 {
+		m.Namespaces = []string{defaultNamespace}
+	}
+	return m, nil
+}
+
+func (c *Client) get(appID, api string) (*http.Response, []byte, error) {
+	rawURL := c.servers[rand.Intn(len(c.servers))] + api
+	headers, err := signHeaders(appID, rawURL, c.secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := c.c.Get(c.ctx, rawURL, headers)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotModified {
+		return nil, nil, fmt.Errorf("call %s failed, statusCode: %d, resp body: %s", api, resp.StatusCode, body)
+	}
+	return resp, body, nil
+}
+
+// PullNamespace pulls and decodes configurations of one namespace
+func (c *Client) PullNamespace(appID, cluster, namespace string) (map[string]interface{}, error) {
+	api := fmt.Sprintf(configAPI, url.PathEscape(appID), url.PathEscape(cluster), url.PathEscape(namespace))
+	resp, body, err := c.get(appID, api)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, fmt.Errorf("namespace %s not modified", namespace)
+	}
+	cfg := &Config{}
+	if err := serializers.Decode(serializers.JsonEncoder, body, cfg); err != nil {
+		return nil, err
+	}
+	return Decode(namespace, cfg.Configurations)
+}
+
+func (c *Client) pull(m *meta) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	for i := len(m.Namespaces) - 1; i >= 0; i-- {
+		kv, err := c.PullNamespace(m.AppID, m.Cluster, m.Namespaces[i])
+		if err != nil {
+			openlogging.GetLogger().Errorf("pull namespace %s failed: %s", m.Namespaces[i], err)
+			return nil, err
+		}
+		for k, v := range kv {
+			result[k] = v
+		}
+	}
+	return result, nil
+}
+
+// PullConfigs pulls and merges configs of all namespaces
+func (c *Client) PullConfigs(labels ...map[string]string) (map[string]interface{}, error) {
+	var l map[string]string
+	if len(labels) != 0 {
+		l = labels[0]
+	}
+	m, err := c.meta(l)
+	if err != nil {
+		return nil, err
+	}
+	return c.pull(m)
+}
+
+// PullConfig pulls one config
+func (c *Client) PullConfig(key, contentType string, labels map[string]string) (interface{}, error) {
+	configs, err := c.PullConfigs(labels)
+	if err != nil {
+		return nil, err
+	}
+	v, ok := configs[key]
+	if !ok {
+		return nil, config.ErrKeyNotExist
+	}
+	return v, nil
+}
+
+// PushConfigs is not supported, apollo config service is read only
+func (c *Client) PushConfigs(items map[string]interface{}, labels map[string]string) (map[string]interface{}, error) {
+	return nil, config.ErrNotSupported
+}
+
+// DeleteConfigsByKeys is not supported, apollo config service is read only
+func (c *Client) DeleteConfigsByKeys(keys []string, labels map[string]string) (map[string]interface{}, error) {
+	return nil, config.ErrNotSupported
+}
+
+// Watch long polls apollo notifications,
+// after any namespace changed, it calls f with the latest configs of all namespaces
+func (c *Client) Watch(f func(map[string]interface{}), errHandler func(err error), labels map[string]string) error {
+	m, err := c.meta(labels)
+	if err != nil {
+		return err
+	}
+	last, err := c.pull(m)
+	if err != nil {
+		return err
+	}
+	notifications := make([]*Notification, 0, len(m.Namespaces))
+	for _, ns := range m.Namespaces {
+		notifications = append(notifications, &Notification{NamespaceName: ns, NotificationID: -1})
+	}
+	go func() {
+		for c.ctx.Err() == nil {
+			changed, err := c.poll(m, notifications)
+			if err != nil {
+				if c.ctx.Err() != nil {
+					return
+				}
+				errHandler(err)
+				util.Sleep(retryInterval, c.ctx.Done())
+				continue
+			}
+			if !changed {
+				continue
+			}
+			latest, err := c.pull(m)
+			if err != nil {
+				if c.ctx.Err() != nil {
+					return
+				}
+				errHandler(err)
+				util.Sleep(retryInterval, c.ctx.Done())
+				continue
+			}
+			if reflect.DeepEqual(latest, last) {
+				continue
+			}
+			last = latest
+			f(latest)
+		}
+	}()
+	return nil
+}
+
+// poll waits for apollo notifications and updates notification ids, returns true if any namespace changed
+func (c *Client) poll(m *meta, notifications []*Notification) (bool, error) {
+	b, err := serializers.Encode(serializers.JsonEncoder, notifications)
+	if err != nil {
+		return false, err
+	}
+	q := url.Values{}
+	q.Set("appId", m.AppID)
+	q.Set("cluster", m.Cluster)
+	q.Set("notifications", string(b))
+	resp, body, err := c.get(m.AppID, notificationAPI+"?"+q.Encode())
+	if err != nil {
+		return false, err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		return false, nil
+	}
+	result := make([]*Notification, 0)
+	if err := serializers.Decode(serializers.JsonEncoder, body, &result); err != nil {
+		return false, err
+	}
+	for _, r := range result {
+		for _, n := range notifications {
+			if n.NamespaceName == r.NamespaceName {
+				n.NotificationID = r.NotificationID
+			}
+		}
+	}
+	return len(result) != 0, nil
+}
+
+// Close stops watches and cancels long polling requests
+func (c *Client) Close() error {
+	c.cancel()
+	return nil
+}
+
+// Capabilities reports that the client is read only
+func (c *Client) Capabilities() []config.Capability {
+	return []config.Capability{config.CapabilityRead, config.CapabilityWatch}
+}
+
+// Options return settings
+func (c *Client) Options() config.Options {
+	return c.opts
+}
+
+func init() {
+	config.InstallConfigClientPlugin(Name, NewClient, config.CapabilityRead, config.CapabilityWatch)
+}
@@ -0,0 +1,119 @@
+package apollo_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/go-chassis/go-chassis-config/apollo"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeApollo struct {
+	sync.Mutex
+	secret     string
+	namespaces map[string]map[string]string
+	id         int64
+	changed    chan struct{}
+}
+
+func (s *fakeApollo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.secret != "" {
+		sign := apollo.Signature(r.Header.Get("Timestamp"), r.URL.RequestURI(), s.secret)
+		if r.Header.Get("Authorization") != "Apollo app:"+sign {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+	if strings.HasPrefix(r.URL.Path, "/notifications/v2") {
+		select {
+		case <-s.changed:
+		case <-time.After(time.Second):
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		s.Lock()
+		b, _ := json.Marshal([]*apollo.Notification{{NamespaceName: "application", NotificationID: s.id}})
+		s.Unlock()
+		w.Write(b)
+		return
+	}
+	ns := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	s.Lock()
+	defer s.Unlock()
+	b, _ := json.Marshal(&apollo.Config{AppID: "app", Cluster: "default", NamespaceName: ns, Configurations: s.namespaces[ns]})
+	w.Write(b)
+}
+
+func TestClient(t *testing.T) {
+	s := &fakeApollo{
+		secret:  "s3cret",
+		changed: make(chan struct{}, 1),
+		namespaces: map[string]map[string]string{
+			"application":  {"timeout": "1s", "name": "a"},
+			"db.yaml":      {"content": "db:\n  host: 127.0.0.1\n  port: 3306\n"},
+			"feature.json": {"content": `{"feature":{"enabled":true}}`},
+			"nginx.txt":    {"content": "raw"},
+		},
+	}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	c, err := config.NewClient(apollo.Name, config.Options{
+		ServerURI: ts.URL,
+		Labels: map[string]string{
+			config.LabelApp:       "app",
+			apollo.LabelNamespace: "application,db.yaml,feature.json,nginx.txt",
+		},
+		Params: map[string]string{apollo.ParamSecret: "s3cret"},
+	})
+	assert.NoError(t, err)
+	defer c.(io.Closer).Close()
+
+	m, err := c.PullConfigs()
+	assert.NoError(t, err)
+	assert.Equal(t, "1s", m["timeout"])
+	assert.Equal(t, "127.0.0.1", m["db.host"])
+	assert.Equal(t, 3306, m["db.port"])
+	assert.Equal(t, true, m["feature.enabled"])
+	assert.Equal(t, "raw", m["nginx.txt"])
+
+	_, err = c.PullConfig("none", "", nil)
+	assert.Equal(t, config.ErrKeyNotExist, err)
+	_, err = c.PushConfigs(map[string]interface{}{"a": "b"}, nil)
+	assert.Equal(t, config.ErrNotSupported, err)
+
+	events := make(chan map[string]interface{}, 1)
+	err = c.Watch(func(m map[string]interface{}) {
+		events <- m
+	}, func(err error) {
+		t.Log(err)
+	}, nil)
+	assert.NoError(t, err)
+
+	s.Lock()
+	s.namespaces["application"] = map[string]string{"timeout": "2s"}
+	s.id++
+	s.Unlock()
+	s.changed <- struct{}{}
+	select {
+	case m := <-events:
+		assert.Equal(t, "2s", m["timeout"])
+		assert.Nil(t, m["name"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, apollo.FormatProperties, apollo.Format("application"))
+	assert.Equal(t, apollo.FormatProperties, apollo.Format("a.properties"))
+	assert.Equal(t, apollo.FormatYML, apollo.Format("a.yml"))
+	assert.Equal(t, apollo.FormatJSON, apollo.Format("a.JSON"))
+}
@@ -0,0 +1,124 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apollo
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chassis/go-chassis-config/pkg/util"
+	"github.com/go-chassis/go-chassis-config/serializers"
+)
+
+// namespace formats
+const (
+	FormatProperties = "properties"
+	FormatYAML       = "yaml"
+	FormatYML        = "yml"
+	FormatJSON       = "json"
+	FormatXML        = "xml"
+	FormatTXT        = "txt"
+)
+
+const (
+	headerAuthorization = "Authorization"
+	headerTimestamp     = "Timestamp"
+	contentKey          = "content"
+)
+
+// Format returns the format of a namespace, it is decided by name suffix,
+// namespace without a known suffix is properties
+func Format(namespace string) string {
+	i := strings.LastIndex(namespace, ".")
+	if i == -1 {
+		return FormatProperties
+	}
+	switch suffix := strings.ToLower(namespace[i+1:]); suffix {
+	case FormatYAML, FormatYML, FormatJSON, FormatXML, FormatTXT, FormatProperties:
+		return suffix
+	}
+	return FormatProperties
+}
+
+// normalize removes the .properties suffix, apollo does not use it in namespace name
+func normalize(namespace string) string {
+	if strings.HasSuffix(strings.ToLower(namespace), "."+FormatProperties) {
+		return namespace[:len(namespace)-len(FormatProperties)-1]
+	}
+	return namespace
+}
+
+// Decode converts configurations of a namespace to flat kv.
+// yaml and json documents are flattened to dotted keys,
+// xml and txt namespaces are returned as a single kv which key is the namespace name
+func Decode(namespace string, configurations map[string]string) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	var serializer string
+	switch Format(namespace) {
+	case FormatYAML, FormatYML:
+		serializer = serializers.YamlEncoder
+	case FormatJSON:
+		serializer = serializers.JsonEncoder
+	case FormatXML, FormatTXT:
+		result[namespace] = configurations[contentKey]
+		return result, nil
+	default:
+		for k, v := range configurations {
+			result[k] = v
+		}
+		return result, nil
+	}
+	content := configurations[contentKey]
+	if content == "" {
+		return result, nil
+	}
+	doc := make(map[string]interface{})
+	if err := serializers.Decode(serializer, []byte(content), &doc); err != nil {
+		return nil, err
+	}
+	return util.Flatten(doc), nil
+}
+
+// Signature generates apollo access key signature of a request
+func Signature(timestamp, pathWithQuery, secret string) string {
+	h := hmac.New(sha1.New, []byte(secret))
+	h.Write([]byte(timestamp + "\n" + pathWithQuery))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// signHeaders returns headers with apollo access key signature
+func signHeaders(appID, rawURL, secret string) (http.Header, error) {
+	headers := make(http.Header)
+	if secret == "" {
+		return headers, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	ts := strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+	headers.Set(headerAuthorization, "Apollo "+appID+":"+Signature(ts, u.RequestURI(), secret))
+	headers.Set(headerTimestamp, ts)
+	return headers, nil
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// statuses of batch operations
+const (
+	OpApplied = "applied"
+	OpFailed  = "failed"
+	OpSkipped = "skipped"
+)
+
+// BatchOp puts and deletes keys of a label set, puts are applied before deletes
+type BatchOp struct {
+	// Labels of configs, default labels of client are used if it is empty
+	Labels map[string]string
+	Put    map[string]interface{}
+	Delete []string
+	// If is checked before the operation, it is not checked if it is empty.
+	// a ConditionalWriter checks it with the first write of the operation, puts are written first
+	If Precondition
+}
+
+// OpResult is the result of a batch operation
+type OpResult struct {
+	// Status is OpApplied, OpFailed or OpSkipped
+	Status string
+	// Results are returned by writes of plugin
+	Results []map[string]interface{}
+	Err     error
+}
+
+// BatchResult reports operations of a batch in order
+type BatchResult struct {
+	// Atomic is true if the batch is applied all or nothing, so no operation is applied if any failed
+	Atomic bool
+	Ops    []OpResult
+}
+
+// BatchError is returned if an operation of a batch failed, Result reports which operations are applied
+type BatchError struct {
+	Result *BatchResult
+}
+
+// Error describes the failed operation
+func (e *BatchError) Error() string {
+	applied := 0
+	for i, op := range e.Result.Ops {
+		switch op.Status {
+		case OpApplied:
+			applied++
+		case OpFailed:
+			return fmt.Sprintf("batch operation %d failed: %s, %d operation(s) applied", i, op.Err, applied)
+		}
+	}
+	return "batch failed"
+}
+
+// BatchWriter is implemented by clients which apply a batch all or nothing
+type BatchWriter interface {
+	Batch(ops []BatchOp) (*BatchResult, error)
+}
+
+// Batch applies operations in order, it uses BatchWriter if client implements it,
+// or else operations are applied one by one and the batch stops at the first failure,
+// later operations are skipped and the result reports the partial failure.
+// a failed operation of a non atomic batch may have applied its puts if its deletes failed
+func Batch(c Client, ops []BatchOp) (*BatchResult, error) {
+	if len(ops) == 0 {
+		return nil, errors.New("batch is empty")
+	}
+	if w, ok := c.(BatchWriter); ok && Supports(c, CapabilityWrite) {
+		return w.Batch(ops)
+	}
+	if _, ok := AsWriter(c); !ok {
+		return nil, ErrNotSupported
+	}
+	result := &BatchResult{Ops: make([]OpResult, len(ops))}
+	failed := false
+	for i, op := range ops {
+		if failed {
+			result.Ops[i].Status = OpSkipped
+			continue
+		}
+		result.Ops[i] = apply(c, op)
+		failed = result.Ops[i].Status == OpFailed
+	}
+	if failed {
+		return result, &BatchError{Result: result}
+	}
+	return result, nil
+}
+
+// apply applies an operation, if client implements ConditionalWriter, the precondition is checked
+// by the client with the first write of the operation, or else it is checked by check before writing
+func apply(c Client, op BatchOp) OpResult {
+	r := OpResult{Status: OpFailed}
+	w, native := c.(ConditionalWriter)
+	conditional := op.If.Revision != "" || len(op.If.Keys) != 0
+	if conditional && !native {
+		if r.Err = check(c, op.Labels, op.If); r.Err != nil {
+			return r
+		}
+		conditional = false
+	}
+	if len(op.Put) != 0 {
+		var result map[string]interface{}
+		var err error
+		if conditional {
+			result, err = w.PushConfigsIf(op.Put, op.Labels, op.If)
+			conditional = false
+		} else {
+			result, err = c.PushConfigs(op.Put, op.Labels)
+		}
+		if err != nil {
+			r.Err = err
+			return r
+		}
+		r.Results = append(r.Results, result)
+	}
+	if len(op.Delete) != 0 {
+		var result map[string]interface{}
+		var err error
+		if conditional {
+			result, err = w.DeleteConfigsByKeysIf(op.Delete, op.Labels, op.If)
+		} else {
+			result, err = c.DeleteConfigsByKeys(op.Delete, op.Labels)
+		}
+		if err != nil {
+			r.Err = err
+			return r
+		}
+		r.Results = append(r.Results, result)
+	}
+	r.Status = OpApplied
+	return r
+}
+
+// NewAtomicFailure returns the result and error of an atomic batch which failed at an operation
+func NewAtomicFailure(n, failed int, err error) (*BatchResult, error) {
+	result := &BatchResult{Atomic: true, Ops: make([]OpResult, n)}
+	for i := range result.Ops {
+		result.Ops[i].Status = OpSkipped
+	}
+	result.Ops[failed] = OpResult{Status: OpFailed, Err: err}
+	return result, &BatchError{Result: result}
+}
@@ -0,0 +1,88 @@
+package config_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingClient struct {
+	memClient
+}
+
+func (c *failingClient) PushConfigs(items map[string]interface{}, labels map[string]string) (map[string]interface{}, error) {
+	if labels["fail"] != "" {
+		return nil, errors.New(labels["fail"])
+	}
+	return c.memClient.PushConfigs(items, labels)
+}
+
+func TestBatch(t *testing.T) {
+	c := &failingClient{memClient{kv: map[string]interface{}{"a": "1"}}}
+	r, err := config.Batch(c, []config.BatchOp{
+		{Put: map[string]interface{}{"b": "2"}, Delete: []string{"a"}},
+		{Put: map[string]interface{}{"c": "3"}},
+	})
+	assert.NoError(t, err)
+	assert.False(t, r.Atomic)
+	assert.Equal(t, config.OpApplied, r.Ops[1].Status)
+	assert.Equal(t, map[string]interface{}{"b": "2", "c": "3"}, c.kv)
+
+	r, err = config.Batch(c, []config.BatchOp{
+		{Put: map[string]interface{}{"d": "4"}},
+		{Put: map[string]interface{}{"e": "5"}, Labels: map[string]string{"fail": "timeout"}},
+		{Delete: []string{"b"}},
+	})
+	assert.EqualError(t, err, "batch operation 1 failed: timeout, 1 operation(s) applied")
+	assert.Equal(t, []string{config.OpApplied, config.OpFailed, config.OpSkipped},
+		[]string{r.Ops[0].Status, r.Ops[1].Status, r.Ops[2].Status})
+	assert.Equal(t, "2", c.kv["b"])
+
+	_, err = config.Batch(c, []config.BatchOp{{Delete: []string{"b"}, If: config.Precondition{Keys: map[string]string{"b": ""}}}})
+	assert.IsType(t, &config.ConflictError{}, err.(*config.BatchError).Result.Ops[0].Err)
+}
+
+// conditionalClient records conditional writes
+type conditionalClient struct {
+	memClient
+	calls []string
+}
+
+func (c *conditionalClient) Revisions(labels map[string]string) (*config.Revisions, error) {
+	c.calls = append(c.calls, "Revisions")
+	return config.RevisionsOf(c.kv), nil
+}
+
+func (c *conditionalClient) PushConfigsIf(items map[string]interface{}, labels map[string]string, pre config.Precondition) (map[string]interface{}, error) {
+	c.calls = append(c.calls, "PushConfigsIf")
+	if err := pre.Check(config.RevisionsOf(c.kv)); err != nil {
+		return nil, err
+	}
+	return c.PushConfigs(items, labels)
+}
+
+func (c *conditionalClient) DeleteConfigsByKeysIf(keys []string, labels map[string]string, pre config.Precondition) (map[string]interface{}, error) {
+	c.calls = append(c.calls, "DeleteConfigsByKeysIf")
+	if err := pre.Check(config.RevisionsOf(c.kv)); err != nil {
+		return nil, err
+	}
+	return c.DeleteConfigsByKeys(keys, labels)
+}
+
+func TestBatch_ConditionalWriter(t *testing.T) {
+	c := &conditionalClient{memClient: memClient{kv: map[string]interface{}{"a": "1", "b": "2"}}}
+	_, err := config.Batch(c, []config.BatchOp{
+		{Put: map[string]interface{}{"c": "3"}, Delete: []string{"a"}, If: config.Precondition{Keys: map[string]string{"c": ""}}},
+		{Delete: []string{"b"}, If: config.Precondition{Keys: map[string]string{"b": config.ETag("2")}}},
+		{Put: map[string]interface{}{"d": "4"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"PushConfigsIf", "DeleteConfigsByKeysIf"}, c.calls)
+	assert.Equal(t, map[string]interface{}{"c": "3", "d": "4"}, c.kv)
+
+	_, err = config.Batch(c, []config.BatchOp{{Delete: []string{"c"}, If: config.Precondition{Keys: map[string]string{"c": ""}}}})
+	assert.IsType(t, &config.ConflictError{}, err.(*config.BatchError).Result.Ops[0].Err)
+	assert.Equal(t, "3", c.kv["c"])
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-mesh/openlogging"
+)
+
+// BindOptions is the settings of Bind
+type BindOptions struct {
+	// Labels of configs, default labels of client are used if it is empty
+	Labels map[string]string
+	// Validate checks a new value before it replaces the current one, an error keeps the current one
+	Validate func(v interface{}) error
+	// OnChange is called with the former and the new value after a change is applied
+	OnChange func(old, new interface{})
+	// ErrHandler receives watch, binding and validation errors, they are logged if it is nil
+	ErrHandler func(err error)
+}
+
+// Binding keeps a struct up to date with configs of a client
+type Binding struct {
+	c      Client
+	prefix string
+	typ    reflect.Type
+	opts   BindOptions
+
+	value  atomic.Value
+	mu     sync.Mutex
+	closed bool
+	//changed signals the worker to update, changes before the worker updates are coalesced
+	changed chan struct{}
+	done    chan struct{}
+}
+
+// Bind binds configs under prefix to target like Unmarshal, then watches configs, pulls and binds them again on every change.
+// target must be a pointer to struct and it holds the initial value only,
+// each change is bound to a new struct which is validated, then Load returns it.
+// updates run on a worker goroutine of the binding, so a slow OnChange does not block the watch of client.
+// fields are reset on every change, so use default tags instead of presetting fields of target
+func Bind(c Client, prefix string, target interface{}, opts BindOptions) (*Binding, error) {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, ErrInvalidTarget
+	}
+	b := &Binding{
+		c:      c,
+		prefix: prefix,
+		typ:    rv.Elem().Type(),
+		opts:   opts,
+
+		changed: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	kv, err := c.PullConfigs(opts.Labels)
+	if err != nil {
+		return nil, err
+	}
+	v, err := b.bind(kv)
+	if err != nil {
+		return nil, err
+	}
+	rv.Elem().Set(reflect.ValueOf(v).Elem())
+	b.value.Store(v)
+	go b.run()
+	if err := c.Watch(b.notify, b.handleErr, opts.Labels); err != nil {
+		b.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// notify is the watch callback, it only signals the worker
+func (b *Binding) notify(map[string]interface{}) {
+	select {
+	case b.changed <- struct{}{}:
+	default:
+	}
+}
+
+// run updates after each signal until the binding is closed
+func (b *Binding) run() {
+	for {
+		select {
+		case <-b.changed:
+		case <-b.done:
+			return
+		}
+		b.update()
+	}
+}
+
+// bind decodes configs to a new struct and validates it
+func (b *Binding) bind(kv map[string]interface{}) (interface{}, error) {
+	v := reflect.New(b.typ).Interface()
+	if err := UnmarshalMap(kv, b.prefix, v); err != nil {
+		return nil, err
+	}
+	if b.opts.Validate != nil {
+		if err := b.opts.Validate(v); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// update pulls configs again and binds them, because callback of some plugins,
+// like config_center, only carries changed keys
+func (b *Binding) update() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	kv, err := b.c.PullConfigs(b.opts.Labels)
+	if err != nil {
+		b.handleErr(err)
+		return
+	}
+	v, err := b.bind(kv)
+	if err != nil {
+		b.handleErr(err)
+		return
+	}
+	old := b.value.Load()
+	if reflect.DeepEqual(old, v) {
+		return
+	}
+	b.value.Store(v)
+	if b.opts.OnChange != nil {
+		b.opts.OnChange(old, v)
+	}
+}
+
+func (b *Binding) handleErr(err error) {
+	if b.opts.ErrHandler != nil {
+		b.opts.ErrHandler(err)
+		return
+	}
+	openlogging.GetLogger().Errorf("bind configs of [%s] failed: %s", b.prefix, err)
+}
+
+// Load returns the current value, it is a pointer of the same type as target of Bind.
+// the value must not be modified, it is shared by all callers
+func (b *Binding) Load() interface{} {
+	return b.value.Load()
+}
+
+// Close stops applying changes, the watch of client lasts until the client is closed
+func (b *Binding) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return errors.New("binding is already closed")
+	}
+	b.closed = true
+	close(b.done)
+	return nil
+}
@@ -0,0 +1,132 @@
+package config_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/stretchr/testify/assert"
+)
+
+type watchClient struct {
+	mapReader
+	mu sync.Mutex
+	f  func(map[string]interface{})
+}
+
+func (c *watchClient) PullConfigs(labels ...map[string]string) (map[string]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.kv, nil
+}
+
+func (c *watchClient) Watch(f func(map[string]interface{}), errHandler func(err error), labels map[string]string) error {
+	c.f = f
+	return nil
+}
+
+// change replaces configs and calls watch callback with the changed keys only, like config_center
+func (c *watchClient) change(kv, changed map[string]interface{}) {
+	c.mu.Lock()
+	c.kv = kv
+	c.mu.Unlock()
+	c.f(changed)
+}
+
+type Limit struct {
+	QPS   int    `config:"qps" default:"100"`
+	Level string `config:"level"`
+}
+
+func TestBind(t *testing.T) {
+	c := &watchClient{mapReader: mapReader{kv: map[string]interface{}{"limit.level": "low"}}}
+	changes := make(chan [2]*Limit, 10)
+	errs := make(chan error, 10)
+	l := &Limit{}
+	b, err := config.Bind(c, "limit", l, config.BindOptions{
+		Validate: func(v interface{}) error {
+			if v.(*Limit).QPS <= 0 {
+				return errors.New("qps must be positive")
+			}
+			return nil
+		},
+		OnChange: func(old, new interface{}) {
+			changes <- [2]*Limit{old.(*Limit), new.(*Limit)}
+		},
+		ErrHandler: func(err error) {
+			errs <- err
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &Limit{QPS: 100, Level: "low"}, l)
+	assert.Equal(t, l, b.Load())
+
+	c.change(map[string]interface{}{"limit.qps": "200", "limit.level": "low"}, map[string]interface{}{"limit.qps": "200"})
+	change := <-changes
+	assert.Equal(t, 100, change[0].QPS)
+	assert.Equal(t, &Limit{QPS: 200, Level: "low"}, change[1])
+	assert.Equal(t, &Limit{QPS: 200, Level: "low"}, b.Load())
+
+	t.Run("invalid value is not applied", func(t *testing.T) {
+		c.change(map[string]interface{}{"limit.qps": "-1", "limit.level": "low"}, map[string]interface{}{"limit.qps": "-1"})
+		<-errs
+		c.change(map[string]interface{}{"limit.qps": "x", "limit.level": "low"}, map[string]interface{}{"limit.qps": "x"})
+		<-errs
+		assert.Equal(t, 200, b.Load().(*Limit).QPS)
+	})
+	t.Run("unchanged value is not applied and deleted key resets only its field", func(t *testing.T) {
+		c.change(map[string]interface{}{"limit.qps": 200, "limit.level": "low", "other": 1}, map[string]interface{}{"other": 1})
+		//event of deletion only carries the deleted key
+		c.change(map[string]interface{}{"limit.qps": 200}, map[string]interface{}{"limit.level": "low"})
+		change := <-changes
+		assert.Equal(t, &Limit{QPS: 200, Level: "low"}, change[0])
+		assert.Equal(t, &Limit{QPS: 200}, change[1])
+		assert.Equal(t, &Limit{QPS: 200}, b.Load())
+	})
+	t.Run("closed binding ignores changes", func(t *testing.T) {
+		assert.NoError(t, b.Close())
+		c.change(map[string]interface{}{"limit.qps": "300"}, map[string]interface{}{"limit.qps": "300"})
+		select {
+		case <-changes:
+			t.Fatal("closed binding applied a change")
+		case <-time.After(50 * time.Millisecond):
+		}
+		assert.Equal(t, 200, b.Load().(*Limit).QPS)
+	})
+	_, err = config.Bind(c, "limit", Limit{}, config.BindOptions{})
+	assert.Equal(t, config.ErrInvalidTarget, err)
+}
+
+func TestBind_SlowOnChange(t *testing.T) {
+	c := &watchClient{mapReader: mapReader{kv: map[string]interface{}{"limit.qps": "1"}}}
+	gate := make(chan struct{})
+	applied := make(chan int, 10)
+	b, err := config.Bind(c, "limit", &Limit{}, config.BindOptions{
+		OnChange: func(old, new interface{}) {
+			<-gate
+			applied <- new.(*Limit).QPS
+		},
+	})
+	assert.NoError(t, err)
+	changed := make(chan struct{})
+	go func() {
+		for _, v := range []string{"2", "3", "4"} {
+			c.change(map[string]interface{}{"limit.qps": v}, map[string]interface{}{"limit.qps": v})
+		}
+		close(changed)
+	}()
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("watch callback is blocked by OnChange")
+	}
+	close(gate)
+	for qps := range applied {
+		if qps == 4 {
+			break
+		}
+	}
+	assert.NoError(t, b.Close())
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"errors"
+	"time"
+)
+
+// Capability is an optional feature of a plugin
+type Capability string
+
+// capabilities
+const (
+	//CapabilityRead means plugin supports PullConfigs and PullConfig
+	CapabilityRead Capability = "read"
+	//CapabilityWrite means plugin supports PushConfigs and DeleteConfigsByKeys
+	CapabilityWrite Capability = "write"
+	//CapabilityWatch means plugin supports Watch
+	CapabilityWatch Capability = "watch"
+	//CapabilityHistory means plugin keeps history of configs
+	CapabilityHistory Capability = "history"
+	//CapabilityList means plugin lists keys without values
+	CapabilityList Capability = "list"
+)
+
+// ErrNotSupported is returned by a plugin which does not support the operation
+var ErrNotSupported = errors.New("operation is not supported by this plugin")
+
+// Reader pulls configs
+type Reader interface {
+	//PullConfigs pull all configs from remote
+	PullConfigs(labels ...map[string]string) (map[string]interface{}, error)
+	//PullConfig pull one config from remote
+	PullConfig(key, contentType string, labels map[string]string) (interface{}, error)
+}
+
+// Writer pushes and deletes configs
+type Writer interface {
+	// PushConfigs push config to cc
+	PushConfigs(data map[string]interface{}, labels map[string]string) (map[string]interface{}, error)
+	// DeleteConfigsByKeys delete config for cc by keys
+	DeleteConfigsByKeys(keys []string, labels map[string]string) (map[string]interface{}, error)
+}
+
+// Watcher watches changes of configs
+type Watcher interface {
+	//Watch get kv change results, you can compare them with local kv cache and refresh local cache
+	Watch(f func(map[string]interface{}), errHandler func(err error), labels map[string]string) error
+}
+
+// Revision is a historical value of a config, Value is nil if the key is deleted in the revision
+type Revision struct {
+	Key       string
+	Revision  string
+	Value     interface{}
+	Author    string
+	Timestamp time.Time
+}
+
+// Historian lists history of a config, latest revision first
+type Historian interface {
+	History(key string, labels map[string]string) ([]Revision, error)
+}
+
+// Lister lists keys without pulling values
+type Lister interface {
+	ListKeys(labels map[string]string) ([]string, error)
+}
+
+// CapabilityReporter is implemented by clients which do not support all features of their methods,
+// for example a read only plugin implements PushConfigs by returning ErrNotSupported
+type CapabilityReporter interface {
+	Capabilities() []Capability
+}
+
+// Capabilities reports capabilities of a client, it uses CapabilityReporter if client implements it,
+// or else capabilities are decided by interfaces the client implements
+func Capabilities(c interface{}) []Capability {
+	if r, ok := c.(CapabilityReporter); ok {
+		return r.Capabilities()
+	}
+	result := make([]Capability, 0, 5)
+	if _, ok := c.(Reader); ok {
+		result = append(result, CapabilityRead)
+	}
+	if _, ok := c.(Writer); ok {
+		result = append(result, CapabilityWrite)
+	}
+	if _, ok := c.(Watcher); ok {
+		result = append(result, CapabilityWatch)
+	}
+	if _, ok := c.(Historian); ok {
+		result = append(result, CapabilityHistory)
+	}
+	if _, ok := c.(Lister); ok {
+		result = append(result, CapabilityList)
+	}
+	return result
+}
+
+// Supports reports whether client has the capability
+func Supports(c interface{}, capability Capability) bool {
+	for _, cc := range Capabilities(c) {
+		if cc == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// AsReader returns the client as Reader if it supports reading
+func AsReader(c interface{}) (Reader, bool) {
+	r, ok := c.(Reader)
+	return r, ok && Supports(c, CapabilityRead)
+}
+
+// AsWriter returns the client as Writer if it supports writing
+func AsWriter(c interface{}) (Writer, bool) {
+	w, ok := c.(Writer)
+	return w, ok && Supports(c, CapabilityWrite)
+}
+
+// AsWatcher returns the client as Watcher if it supports watching
+func AsWatcher(c interface{}) (Watcher, bool) {
+	w, ok := c.(Watcher)
+	return w, ok && Supports(c, CapabilityWatch)
+}
+
+// AsHistorian returns the client as Historian if it keeps history
+func AsHistorian(c interface{}) (Historian, bool) {
+	h, ok := c.(Historian)
+	return h, ok && Supports(c, CapabilityHistory)
+}
+
+// AsLister returns the client as Lister if it lists keys
+func AsLister(c interface{}) (Lister, bool) {
+	l, ok := c.(Lister)
+	return l, ok && Supports(c, CapabilityList)
+}
@@ -0,0 +1,47 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/stretchr/testify/assert"
+)
+
+type readOnlyClient struct {
+	config.Client
+}
+
+func (c *readOnlyClient) Capabilities() []config.Capability {
+	return []config.Capability{config.CapabilityRead}
+}
+
+type listClient struct {
+	config.Client
+}
+
+func (c *listClient) ListKeys(labels map[string]string) ([]string, error) {
+	return []string{"a"}, nil
+}
+
+func TestCapabilities(t *testing.T) {
+	ro := &readOnlyClient{}
+	assert.Equal(t, []config.Capability{config.CapabilityRead}, config.Capabilities(ro))
+	_, ok := config.AsReader(ro)
+	assert.True(t, ok)
+	_, ok = config.AsWriter(ro)
+	assert.False(t, ok)
+	_, ok = config.AsWatcher(ro)
+	assert.False(t, ok)
+
+	lc := &listClient{}
+	assert.Equal(t, []config.Capability{config.CapabilityRead, config.CapabilityWrite,
+		config.CapabilityWatch, config.CapabilityList}, config.Capabilities(lc))
+	l, ok := config.AsLister(lc)
+	assert.True(t, ok)
+	keys, err := l.ListKeys(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, keys)
+	_, ok = config.AsHistorian(lc)
+	assert.False(t, ok)
+	assert.False(t, config.Supports(nil, config.CapabilityRead))
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/go-chassis/go-chassis-config/serializers"
+)
+
+// Revisions is the current revisions of a label set and its keys, revisions are opaque strings defined by plugins
+type Revisions struct {
+	// Revision of the label set, it changes after any key of label set changed
+	Revision string
+	// Keys holds revisions of existing keys
+	Keys map[string]string
+}
+
+// Precondition is the expected revisions of a conditional write
+type Precondition struct {
+	// Revision is the expected revision of label set, empty string means it is not checked
+	Revision string
+	// Keys holds expected revisions of keys, empty string means the key must not exist
+	Keys map[string]string
+}
+
+// ConflictError is returned by a conditional write if a revision is not the expected one
+type ConflictError struct {
+	// Key is empty if the revision of label set conflicts
+	Key      string
+	Expected string
+	Current  string
+}
+
+// Error describes the conflict
+func (e *ConflictError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("revision conflicts, expected [%s], current [%s]", e.Expected, e.Current)
+	}
+	return fmt.Sprintf("revision of key [%s] conflicts, expected [%s], current [%s]", e.Key, e.Expected, e.Current)
+}
+
+// Check returns a *ConflictError if current revisions do not satisfy the precondition
+func (p Precondition) Check(current *Revisions) error {
+	if p.Revision != "" && p.Revision != current.Revision {
+		return &ConflictError{Expected: p.Revision, Current: current.Revision}
+	}
+	keys := make([]string, 0, len(p.Keys))
+	for k := range p.Keys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if p.Keys[k] != current.Keys[k] {
+			return &ConflictError{Key: k, Expected: p.Keys[k], Current: current.Keys[k]}
+		}
+	}
+	return nil
+}
+
+// ConditionalWriter is implemented by clients which check revisions and write atomically
+type ConditionalWriter interface {
+	Revisions(labels map[string]string) (*Revisions, error)
+	PushConfigsIf(items map[string]interface{}, labels map[string]string, pre Precondition) (map[string]interface{}, error)
+	DeleteConfigsByKeysIf(keys []string, labels map[string]string, pre Precondition) (map[string]interface{}, error)
+}
+
+// ETag returns a revision of a value, equal values have equal etags
+func ETag(v interface{}) string {
+	b, err := serializers.Encode(serializers.JsonEncoder, v)
+	if err != nil {
+		b = []byte(fmt.Sprintf("%#v", v))
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
+
+// RevisionsOf returns revisions computed by ETag of configs
+func RevisionsOf(kv map[string]interface{}) *Revisions {
+	r := &Revisions{Revision: ETag(kv), Keys: make(map[string]string, len(kv))}
+	for k, v := range kv {
+		r.Keys[k] = ETag(v)
+	}
+	return r
+}
+
+// GetRevisions returns current revisions of a label set, it uses ConditionalWriter if client implements it,
+// or else revisions are computed by ETag of pulled configs
+func GetRevisions(c Reader, labels map[string]string) (*Revisions, error) {
+	if w, ok := c.(ConditionalWriter); ok {
+		return w.Revisions(labels)
+	}
+	kv, err := c.PullConfigs(labels)
+	if err != nil {
+		return nil, err
+	}
+	return RevisionsOf(kv), nil
+}
+
+// PushConfigsIf pushes items only if current revisions satisfy the precondition, it uses ConditionalWriter
+// if client implements it, or else it emulates the check by ETag of pulled configs,
+// the emulated check and write are not atomic, a write may still happen between them
+func PushConfigsIf(c Client, items map[string]interface{}, labels map[string]string, pre Precondition) (map[string]interface{}, error) {
+	if w, ok := c.(ConditionalWriter); ok {
+		return w.PushConfigsIf(items, labels, pre)
+	}
+	if err := check(c, labels, pre); err != nil {
+		return nil, err
+	}
+	return c.PushConfigs(items, labels)
+}
+
+// DeleteConfigsByKeysIf deletes keys only if current revisions satisfy the precondition, like PushConfigsIf
+func DeleteConfigsByKeysIf(c Client, keys []string, labels map[string]string, pre Precondition) (map[string]interface{}, error) {
+	if w, ok := c.(ConditionalWriter); ok {
+		return w.DeleteConfigsByKeysIf(keys, labels, pre)
+	}
+	if err := check(c, labels, pre); err != nil {
+		return nil, err
+	}
+	return c.DeleteConfigsByKeys(keys, labels)
+}
+
+func check(c Client, labels map[string]string, pre Precondition) error {
+	if _, ok := AsWriter(c); !ok {
+		return ErrNotSupported
+	}
+	current, err := GetRevisions(c, labels)
+	if err != nil {
+		return err
+	}
+	return pre.Check(current)
+}
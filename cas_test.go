@@ -0,0 +1,50 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/stretchr/testify/assert"
+)
+
+type memClient struct {
+	config.Client
+	kv map[string]interface{}
+}
+
+func (c *memClient) PullConfigs(labels ...map[string]string) (map[string]interface{}, error) {
+	return c.kv, nil
+}
+
+func (c *memClient) PushConfigs(items map[string]interface{}, labels map[string]string) (map[string]interface{}, error) {
+	for k, v := range items {
+		c.kv[k] = v
+	}
+	return nil, nil
+}
+
+func (c *memClient) DeleteConfigsByKeys(keys []string, labels map[string]string) (map[string]interface{}, error) {
+	for _, k := range keys {
+		delete(c.kv, k)
+	}
+	return nil, nil
+}
+
+func TestPushConfigsIf(t *testing.T) {
+	c := &memClient{kv: map[string]interface{}{"a": "1"}}
+	r, err := config.GetRevisions(c, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, config.ETag("1"), r.Keys["a"])
+
+	_, err = config.PushConfigsIf(c, map[string]interface{}{"b": "2"}, nil,
+		config.Precondition{Revision: r.Revision, Keys: map[string]string{"a": config.ETag("1"), "b": ""}})
+	assert.NoError(t, err)
+	_, err = config.PushConfigsIf(c, map[string]interface{}{"b": "3"}, nil, config.Precondition{Revision: r.Revision})
+	assert.IsType(t, &config.ConflictError{}, err)
+	_, err = config.DeleteConfigsByKeysIf(c, []string{"b"}, nil, config.Precondition{Keys: map[string]string{"b": ""}})
+	assert.Equal(t, &config.ConflictError{Key: "b", Current: config.ETag("2")}, err)
+	assert.Equal(t, "2", c.kv["b"])
+
+	_, err = config.PushConfigsIf(&readOnlyClient{}, map[string]interface{}{"b": "3"}, nil, config.Precondition{})
+	assert.Equal(t, config.ErrNotSupported, err)
+}
@@ -7,9 +7,7 @@ import (
 	"github.com/go-mesh/openlogging"
 )
 
-var configClientPlugins = make(map[string]func(options Options) (Client, error))
-
-//const
+// const
 const (
 	LabelService     = "serviceName"
 	LabelVersion     = "version"
@@ -17,40 +15,36 @@ const (
 	LabelApp         = "app"
 )
 
-//DefaultClient is config server's client
-var DefaultClient Client
+// errors
+var (
+	ErrKeyNotExist = errors.New("key does not exist")
+)
 
-//InstallConfigClientPlugin install a config client plugin
-func InstallConfigClientPlugin(name string, f func(options Options) (Client, error)) {
-	configClientPlugins[name] = f
-	openlogging.GetLogger().Infof("Installed %s Plugin", name)
-}
+// DefaultClient is config server's client, it is set by NewClient and SetDefaultClient,
+// use GetDefaultClient to read it concurrently
+var DefaultClient Client
 
-//Client is the interface of config server client, it has basic func to interact with config server
+// Client is the interface of config server client, it has basic func to interact with config server.
+// plugins return ErrNotSupported from methods they can not support, and report it by CapabilityReporter
 type Client interface {
-	//PullConfigs pull all configs from remote
-	PullConfigs(labels ...map[string]string) (map[string]interface{}, error)
-	//PullConfig pull one config from remote
-	PullConfig(key, contentType string, labels map[string]string) (interface{}, error)
-	// PushConfigs push config to cc
-	PushConfigs(data map[string]interface{}, labels map[string]string) (map[string]interface{}, error)
-	// DeleteConfigsByKeys delete config for cc by keys
-	DeleteConfigsByKeys(keys []string, labels map[string]string) (map[string]interface{}, error)
-	//Watch get kv change results, you can compare them with local kv cache and refresh local cache
-	Watch(f func(map[string]interface{}), errHandler func(err error), labels map[string]string) error
+	Reader
+	Writer
+	Watcher
 	Options() Options
 }
 
-//NewClient create config client implementation
+// NewClient create config client implementation and sets it as the default client,
+// the former default client is not closed, because it may still be used, for example as a layer
 func NewClient(name string, options Options) (Client, error) {
-	plugins := configClientPlugins[name]
+	plugins := getPlugin(name)
 	if plugins == nil {
 		return nil, errors.New(fmt.Sprintf("plugin [%s] not found", name))
 	}
-	DefaultClient, err := plugins(options)
+	c, err := plugins(options)
 	if err != nil {
 		return nil, err
 	}
+	setClient(DefaultClientName, c)
 	openlogging.GetLogger().Infof("%s plugin is enabled", name)
-	return DefaultClient, nil
+	return c, nil
 }
@@ -9,8 +9,9 @@ import (
 )
 
 func TestEnable(t *testing.T) {
-	c, err := config.NewClient("config_center", config.Options{
+	_, err := config.NewClient("config_center", config.Options{
 		ServerURI: "http://127.0.0.1:30100",
+		Labels:    map[string]string{config.LabelApp: "default"},
 	})
 	assert.NoError(t, err)
 }
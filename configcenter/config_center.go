@@ -20,6 +20,8 @@ package configcenter
 import (
 	"errors"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-chassis/go-chassis-config"
 	"github.com/go-chassis/go-chassis-config/pkg/configcenter"
@@ -34,6 +36,9 @@ const (
 	HeaderUserAgent = "User-Agent"
 	// Name of the Plugin
 	Name = "config_center"
+	//ParamWatchMode is the watch mode, websocket, polling, or empty to poll if websocket can not be connected,
+	//polling interval is WatchDuration of options
+	ParamWatchMode = "watchMode"
 )
 
 var (
@@ -58,7 +63,8 @@ func NewConfigCenter(options config.Options) (config.Client, error) {
 		value = strings.Replace(value, " ", "", -1)
 		cCenters = append(cCenters, value)
 	}
-	d, err := GenerateDimension(options.Labels[config.LabelService], options.Labels[config.LabelVersion], options.Labels[config.LabelApp])
+	d, err := GenerateDimensionWithEnvironment(options.Labels[config.LabelService], options.Labels[config.LabelVersion],
+		options.Labels[config.LabelApp], options.Labels[config.LabelEnvironment])
 	if err != nil {
 		return nil, err
 	}
@@ -66,10 +72,13 @@ func NewConfigCenter(options config.Options) (config.Client, error) {
 	c, err := configcenter.New(configcenter.Options{
 		ConfigServerAddresses: cCenters,
 		DefaultDimension:      d,
+		Env:                   options.Labels[config.LabelEnvironment],
 		TLSConfig:             options.TLSConfig,
 		TenantName:            options.TenantName,
 		EnableSSL:             options.EnableSSL,
 		RefreshPort:           options.RefreshPort,
+		WatchMode:             options.Params[ParamWatchMode],
+		PollInterval:          options.WatchDuration,
 	})
 	if err != nil {
 		return nil, err
@@ -82,6 +91,7 @@ func NewConfigCenter(options config.Options) (config.Client, error) {
 	openlogging.Info("new config center client", openlogging.WithTags(
 		openlogging.Tags{
 			"dimension": d,
+			"env":       options.Labels[config.LabelEnvironment],
 			"ws_port":   options.RefreshPort,
 			"ssl":       options.EnableSSL,
 			"ep":        cCenters,
@@ -91,9 +101,7 @@ func NewConfigCenter(options config.Options) (config.Client, error) {
 
 // PullConfigs is the implementation of ConfigCenter to pull all the configurations from Config-Server
 func (c *ConfigCenter) PullConfigs(labels ...map[string]string) (map[string]interface{}, error) {
-	d := ""
-	var err error
-	d, err = GenerateDimension(c.opts.Labels[config.LabelService], c.opts.Labels[config.LabelVersion], c.opts.Labels[config.LabelApp])
+	d, err := c.dimension()
 	if err != nil {
 		return nil, err
 	}
@@ -109,7 +117,7 @@ func (c *ConfigCenter) PullConfig(key, contentType string, labels map[string]str
 	if len(labels) == 0 {
 		labels = c.opts.Labels
 	}
-	d, err := GenerateDimension(c.opts.Labels[config.LabelService], c.opts.Labels[config.LabelVersion], c.opts.Labels[config.LabelApp])
+	d, err := c.dimension()
 	if err != nil {
 		return nil, err
 	}
@@ -128,6 +136,41 @@ func (c *ConfigCenter) PullConfig(key, contentType string, labels map[string]str
 
 // PushConfigs push configs to ConfigSource cc , success will return { "Result": "Success" }
 func (c *ConfigCenter) PushConfigs(items map[string]interface{}, labels map[string]string) (map[string]interface{}, error) {
+	configApi, err := c.createConfigApi(items, labels)
+	if err != nil {
+		return nil, err
+	}
+	return c.c.AddConfig(configApi)
+}
+
+// PushConfigsResult is like PushConfigs, it returns the typed result of config center
+func (c *ConfigCenter) PushConfigsResult(items map[string]interface{}, labels map[string]string) (*config.WriteResult, error) {
+	configApi, err := c.createConfigApi(items, labels)
+	if err != nil {
+		return nil, err
+	}
+	return writeResult(c.c.AddConfigResult(configApi))
+}
+
+func writeResult(r *configcenter.WriteResult, err error) (*config.WriteResult, error) {
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]config.KeyStatus, len(r.Keys))
+	for k, status := range r.Keys {
+		keys[k] = config.KeyStatus(status)
+	}
+	return &config.WriteResult{
+		Success:  r.Success,
+		Keys:     keys,
+		Message:  r.Result,
+		Revision: r.Revision,
+		Warnings: r.Warnings,
+		Raw:      r.Raw,
+	}, nil
+}
+
+func (c *ConfigCenter) createConfigApi(items map[string]interface{}, labels map[string]string) (*configcenter.CreateConfigApi, error) {
 	if len(items) == 0 {
 		em := "data is empty , which data need to send cc"
 		openlogging.GetLogger().Error(em)
@@ -136,20 +179,35 @@ func (c *ConfigCenter) PushConfigs(items map[string]interface{}, labels map[stri
 	if len(labels) == 0 {
 		labels = c.opts.Labels
 	}
-	d, err := GenerateDimension(c.opts.Labels[config.LabelService], c.opts.Labels[config.LabelVersion], c.opts.Labels[config.LabelApp])
+	d, err := c.dimension()
 	if err != nil {
 		return nil, err
 	}
-	configApi := &configcenter.CreateConfigApi{
+	return &configcenter.CreateConfigApi{
 		DimensionInfo: d,
 		Items:         items,
-	}
-
-	return c.c.AddConfig(configApi)
+	}, nil
 }
 
 // DeleteConfigsByKeys
 func (c *ConfigCenter) DeleteConfigsByKeys(keys []string, labels map[string]string) (map[string]interface{}, error) {
+	configApi, err := c.deleteConfigApi(keys, labels)
+	if err != nil {
+		return nil, err
+	}
+	return c.c.DeleteConfig(configApi)
+}
+
+// DeleteConfigsByKeysResult is like DeleteConfigsByKeys, it returns the typed result of config center
+func (c *ConfigCenter) DeleteConfigsByKeysResult(keys []string, labels map[string]string) (*config.WriteResult, error) {
+	configApi, err := c.deleteConfigApi(keys, labels)
+	if err != nil {
+		return nil, err
+	}
+	return writeResult(c.c.DeleteConfigResult(configApi))
+}
+
+func (c *ConfigCenter) deleteConfigApi(keys []string, labels map[string]string) (*configcenter.DeleteConfigApi, error) {
 	if len(keys) == 0 {
 		em := "not key need to delete for cc, please check keys"
 		openlogging.GetLogger().Error(em)
@@ -158,22 +216,197 @@ func (c *ConfigCenter) DeleteConfigsByKeys(keys []string, labels map[string]stri
 	if len(labels) == 0 {
 		labels = c.opts.Labels
 	}
-	d, err := GenerateDimension(c.opts.Labels[config.LabelService], c.opts.Labels[config.LabelVersion], c.opts.Labels[config.LabelApp])
+	d, err := c.dimension()
 	if err != nil {
 		return nil, err
 	}
-	configApi := &configcenter.DeleteConfigApi{
+	return &configcenter.DeleteConfigApi{
 		DimensionInfo: d,
 		Keys:          keys,
+	}, nil
+}
+
+func conditionErr(err error) error {
+	if e, ok := err.(*configcenter.ConflictError); ok {
+		return &config.ConflictError{Key: e.Key, Expected: e.Expected, Current: e.Current}
 	}
+	if err == configcenter.ErrConditionNotSupported {
+		return config.ErrNotSupported
+	}
+	return err
+}
 
-	return c.c.DeleteConfig(configApi)
+func precondition(pre config.Precondition) *configcenter.Precondition {
+	if pre.Revision == "" && len(pre.Keys) == 0 {
+		return nil
+	}
+	return &configcenter.Precondition{Revision: pre.Revision, Keys: pre.Keys}
+}
+
+// Revisions returns revisions of the dimension of client and its keys,
+// it returns config.ErrNotSupported if config center does not provide revisions api
+func (c *ConfigCenter) Revisions(labels map[string]string) (*config.Revisions, error) {
+	d, err := c.dimension()
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.c.Revisions(d)
+	if err != nil {
+		return nil, conditionErr(err)
+	}
+	return &config.Revisions{Revision: r.Revision, Keys: r.Keys}, nil
 }
+
+// PushConfigsIf pushes items with the precondition, config center checks it and writes atomically,
+// a rejected precondition returns *config.ConflictError
+func (c *ConfigCenter) PushConfigsIf(items map[string]interface{}, labels map[string]string, pre config.Precondition) (map[string]interface{}, error) {
+	configApi, err := c.createConfigApi(items, labels)
+	if err != nil {
+		return nil, err
+	}
+	configApi.If = precondition(pre)
+	resp, err := c.c.AddConfig(configApi)
+	return resp, conditionErr(err)
+}
+
+// DeleteConfigsByKeysIf deletes keys with the precondition, like PushConfigsIf
+func (c *ConfigCenter) DeleteConfigsByKeysIf(keys []string, labels map[string]string, pre config.Precondition) (map[string]interface{}, error) {
+	configApi, err := c.deleteConfigApi(keys, labels)
+	if err != nil {
+		return nil, err
+	}
+	configApi.If = precondition(pre)
+	resp, err := c.c.DeleteConfig(configApi)
+	return resp, conditionErr(err)
+}
+
+// dimension returns the dimension info of client, it includes the environment of client
+func (c *ConfigCenter) dimension() (string, error) {
+	return GenerateDimensionWithEnvironment(c.opts.Labels[config.LabelService], c.opts.Labels[config.LabelVersion],
+		c.opts.Labels[config.LabelApp], c.opts.Labels[config.LabelEnvironment])
+}
+
+func historyErr(err error) error {
+	switch err {
+	case configcenter.ErrHistoryNotSupported:
+		return config.ErrNotSupported
+	case configcenter.ErrRevisionNotExist:
+		return config.ErrRevisionNotExist
+	}
+	return err
+}
+
+func newRevision(item *configcenter.HistoryItem) config.Revision {
+	return config.Revision{
+		Key:       item.Key,
+		Revision:  item.Revision,
+		Value:     item.Value,
+		Author:    item.Author,
+		Timestamp: time.Unix(item.Timestamp, 0),
+	}
+}
+
+// History lists history of a key in the dimension of client, latest first.
+// it returns config.ErrNotSupported if config center does not provide history api
+func (c *ConfigCenter) History(key string, labels map[string]string) ([]config.Revision, error) {
+	d, err := c.dimension()
+	if err != nil {
+		return nil, err
+	}
+	items, err := c.c.History(d, key)
+	if err != nil {
+		return nil, historyErr(err)
+	}
+	result := make([]config.Revision, 0, len(items))
+	for i := range items {
+		result = append(result, newRevision(&items[i]))
+	}
+	return result, nil
+}
+
+// GetRevision returns a revision of a key in the dimension of client
+func (c *ConfigCenter) GetRevision(key, revision string, labels map[string]string) (*config.Revision, error) {
+	d, err := c.dimension()
+	if err != nil {
+		return nil, err
+	}
+	item, err := c.c.Revision(d, key, revision)
+	if err != nil {
+		return nil, historyErr(err)
+	}
+	r := newRevision(item)
+	return &r, nil
+}
+
+// Rollback pushes the value of a revision, the key is deleted if it is deleted in the revision
+func (c *ConfigCenter) Rollback(key, revision string, labels map[string]string) (map[string]interface{}, error) {
+	r, err := c.GetRevision(key, revision, labels)
+	if err != nil {
+		return nil, err
+	}
+	if r.Value == nil {
+		return c.DeleteConfigsByKeys([]string{key}, labels)
+	}
+	return c.PushConfigs(map[string]interface{}{key: r.Value}, labels)
+}
+
 func (c *ConfigCenter) Watch(f func(map[string]interface{}), errHandler func(err error), labels map[string]string) error {
 	return c.c.Watch(f, errHandler)
 }
+
+//Subscribe watches configs of keys matched by filter, subscriptions and watches share one websocket connection
+func (c *ConfigCenter) Subscribe(filter configcenter.Filter, f func(map[string]interface{}), errHandler func(err error)) (*configcenter.Subscription, error) {
+	return c.c.Subscribe(filter, func(e *configcenter.Event, kv map[string]interface{}) {
+		f(kv)
+	}, errHandler)
+}
+
+//WatchEvents delivers changes as events, configs of a event are merged into a local cache and compared with it,
+//a delete event removes its configs from the cache
+func (c *ConfigCenter) WatchEvents(f func([]*config.ChangeEvent), errHandler func(err error), labels map[string]string) error {
+	d, err := c.dimension()
+	if err != nil {
+		return err
+	}
+	cache, err := c.c.Flatten(d)
+	if err != nil {
+		return err
+	}
+	var mu sync.Mutex
+	return c.c.WatchEvents(func(e *configcenter.Event, kv map[string]interface{}) {
+		mu.Lock()
+		deleted := strings.EqualFold(e.Action, config.ActionDelete)
+		latest := make(map[string]interface{}, len(cache))
+		for k, v := range cache {
+			if _, ok := kv[k]; !ok || !deleted {
+				latest[k] = v
+			}
+		}
+		if !deleted {
+			for k, v := range kv {
+				latest[k] = v
+			}
+		}
+		events := config.Diff(cache, latest)
+		cache = latest
+		mu.Unlock()
+		if len(events) == 0 {
+			return
+		}
+		for _, event := range events {
+			event.Dimension = d
+		}
+		f(events)
+	}, errHandler)
+}
+
+//Close stops watch of config center
+func (c *ConfigCenter) Close() error {
+	return c.c.Close()
+}
+
 func init() {
-	config.InstallConfigClientPlugin(Name, NewConfigCenter)
+	config.InstallConfigClientPlugin(Name, NewConfigCenter, config.CapabilityRead, config.CapabilityWrite, config.CapabilityWatch, config.CapabilityHistory)
 }
 
 func (c *ConfigCenter) Options() config.Options {
@@ -1,10 +1,20 @@
 package configcenter_test
 
 import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
 	"github.com/go-chassis/go-chassis-config"
 	"github.com/go-chassis/go-chassis-config/configcenter"
+	pkg "github.com/go-chassis/go-chassis-config/pkg/configcenter"
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
-	"testing"
 )
 
 func TestNewConfigCenter(t *testing.T) {
@@ -13,4 +23,227 @@ func TestNewConfigCenter(t *testing.T) {
 		Labels:    map[string]string{"app": "default"}})
 	assert.NoError(t, err)
 	assert.Equal(t, "default", c.Options().Labels["app"])
+
+	_, err = configcenter.NewConfigCenter(config.Options{
+		ServerURI: "http://",
+		Labels:    map[string]string{config.LabelApp: "default", config.LabelEnvironment: "staging"}})
+	assert.IsType(t, &pkg.EnvironmentError{}, err)
+}
+
+func event(t *testing.T, action string, kv map[string]interface{}) []byte {
+	v, err := json.Marshal(kv)
+	assert.NoError(t, err)
+	b, err := json.Marshal(&pkg.Event{Action: action, Value: string(v)})
+	assert.NoError(t, err)
+	return b
+}
+
+func TestConfigCenter_WatchEvents(t *testing.T) {
+	messages := make(chan []byte, 3)
+	upgrader := websocket.Upgrader{}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			for m := range messages {
+				conn.WriteMessage(websocket.TextMessage, m)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"cart@default":{"a":"1","b":"2"}}`)
+	}))
+	defer s.Close()
+	u, _ := url.Parse(s.URL)
+	c, err := configcenter.NewConfigCenter(config.Options{
+		ServerURI:   s.URL,
+		RefreshPort: u.Port(),
+		Labels:      map[string]string{config.LabelApp: "default", config.LabelService: "cart"}})
+	assert.NoError(t, err)
+	defer c.(io.Closer).Close()
+
+	received := make(chan []*config.ChangeEvent, 3)
+	err = config.WatchEvents(c, func(events []*config.ChangeEvent) {
+		received <- events
+	}, func(err error) {}, nil)
+	assert.NoError(t, err)
+
+	messages <- event(t, "UPDATE", map[string]interface{}{"a": "3", "b": "2", "c": "4"})
+	messages <- event(t, "DELETE", map[string]interface{}{"c": "4"})
+	close(messages)
+
+	for _, expected := range [][]string{{config.ActionUpdate, config.ActionCreate}, {config.ActionDelete}} {
+		select {
+		case events := <-received:
+			assert.Equal(t, len(expected), len(events))
+			for i, e := range events {
+				assert.Equal(t, expected[i], e.Action)
+				assert.Equal(t, "cart@default", e.Dimension)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event received")
+		}
+	}
+}
+
+func TestConfigCenter_History(t *testing.T) {
+	written := make(chan string, 2)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodGet {
+			b, _ := ioutil.ReadAll(r.Body)
+			written <- r.Method + " " + string(b)
+			io.WriteString(w, `{"Result":"Success"}`)
+			return
+		}
+		io.WriteString(w, `{"items":[
+			{"key":"timeout","action":"UPDATE","value":"2s","revision":"2","author":"tom","timestamp":1600000100},
+			{"key":"timeout","action":"DELETE","value":"1s","revision":"1","author":"tom","timestamp":1600000000}]}`)
+	}))
+	defer s.Close()
+	c, err := configcenter.NewConfigCenter(config.Options{
+		ServerURI: s.URL,
+		Labels:    map[string]string{config.LabelApp: "default", config.LabelService: "cart"}})
+	assert.NoError(t, err)
+	defer c.(io.Closer).Close()
+	assert.True(t, config.Supports(c, config.CapabilityHistory))
+
+	h, ok := config.AsHistorian(c)
+	assert.True(t, ok)
+	revisions, err := h.History("timeout", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []config.Revision{
+		{Key: "timeout", Revision: "2", Value: "2s", Author: "tom", Timestamp: time.Unix(1600000100, 0)},
+		{Key: "timeout", Revision: "1", Author: "tom", Timestamp: time.Unix(1600000000, 0)},
+	}, revisions)
+
+	_, err = config.Rollback(c, "timeout", "2", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `POST {"dimensionsInfo":"cart@default","items":{"timeout":"2s"}}`, <-written)
+	_, err = config.Rollback(c, "timeout", "1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `DELETE {"dimensionsInfo":"cart@default","keys":["timeout"]}`, <-written)
+	_, err = config.GetRevision(c, "timeout", "3", nil)
+	assert.Equal(t, config.ErrRevisionNotExist, err)
+}
+
+func TestConfigCenter_PushConfigsIf(t *testing.T) {
+	written := make(chan string, 2)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			io.WriteString(w, `{"revision":"7","keys":{"timeout":"5"}}`)
+			return
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		written <- r.Method + " " + string(b)
+		w.WriteHeader(http.StatusPreconditionFailed)
+		io.WriteString(w, `{"key":"timeout","expected":"4","current":"5"}`)
+	}))
+	defer s.Close()
+	c, err := configcenter.NewConfigCenter(config.Options{
+		ServerURI: s.URL,
+		Labels:    map[string]string{config.LabelApp: "default", config.LabelService: "cart"}})
+	assert.NoError(t, err)
+	defer c.(io.Closer).Close()
+
+	r, err := config.GetRevisions(c, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &config.Revisions{Revision: "7", Keys: map[string]string{"timeout": "5"}}, r)
+
+	_, err = config.PushConfigsIf(c, map[string]interface{}{"timeout": "2s"}, nil, config.Precondition{Keys: map[string]string{"timeout": "4"}})
+	assert.Equal(t, &config.ConflictError{Key: "timeout", Expected: "4", Current: "5"}, err)
+	assert.Equal(t, `POST {"dimensionsInfo":"cart@default","items":{"timeout":"2s"},"if":{"keys":{"timeout":"4"}}}`, <-written)
+	_, err = config.DeleteConfigsByKeysIf(c, []string{"timeout"}, nil, config.Precondition{Revision: "6"})
+	assert.IsType(t, &config.ConflictError{}, err)
+	assert.Equal(t, `DELETE {"dimensionsInfo":"cart@default","keys":["timeout"],"if":{"revision":"6"}}`, <-written)
+}
+
+func TestConfigCenter_PushConfigsResult(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodDelete {
+			io.WriteString(w, `{"Result":"Fail","warnings":["timeout is in use"]}`)
+			return
+		}
+		io.WriteString(w, `{"revision":"8"}`)
+	}))
+	defer s.Close()
+	c, err := configcenter.NewConfigCenter(config.Options{
+		ServerURI: s.URL,
+		Labels:    map[string]string{config.LabelApp: "default", config.LabelService: "cart"}})
+	assert.NoError(t, err)
+	defer c.(io.Closer).Close()
+
+	r, err := config.PushConfigsResult(c, map[string]interface{}{"timeout": "2s"}, nil)
+	assert.NoError(t, err)
+	assert.True(t, r.Success)
+	assert.Equal(t, map[string]config.KeyStatus{"timeout": config.KeyStatusSuccess}, r.Keys)
+	assert.Equal(t, "8", r.Revision)
+	r, err = config.DeleteConfigsByKeysResult(c, []string{"timeout"}, nil)
+	assert.NoError(t, err)
+	assert.False(t, r.Success)
+	assert.Equal(t, "Fail", r.Message)
+	assert.Equal(t, map[string]config.KeyStatus{"timeout": config.KeyStatusFailed}, r.Keys)
+	assert.Equal(t, []string{"timeout is in use"}, r.Warnings)
+}
+
+func TestConfigCenter_Environment(t *testing.T) {
+	written := make(chan string, 1)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, pkg.EnvironmentTesting, r.Header.Get(pkg.HeaderEnvironment))
+		assert.Equal(t, pkg.EnvironmentTesting, r.URL.Query().Get("environment"))
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			assert.Equal(t, "cart@default#1.0.0", r.URL.Query().Get("dimensionsInfo"))
+			io.WriteString(w, `{"cart@default#1.0.0":{"a":"1"}}`)
+			return
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		written <- string(b)
+		io.WriteString(w, `{"Result":"Success"}`)
+	}))
+	defer s.Close()
+	c, err := configcenter.NewConfigCenter(config.Options{
+		ServerURI: s.URL,
+		Labels: map[string]string{config.LabelApp: "default", config.LabelService: "cart",
+			config.LabelVersion: "1.0.0", config.LabelEnvironment: pkg.EnvironmentTesting}})
+	assert.NoError(t, err)
+	defer c.(io.Closer).Close()
+
+	kv, err := c.PullConfigs()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": "1"}, kv)
+	_, err = c.PushConfigs(map[string]interface{}{"a": "2"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"dimensionsInfo":"cart@default#1.0.0","items":{"a":"2"}}`, <-written)
+}
+
+func TestConfigCenter_PushConfigsIfNotSupported(t *testing.T) {
+	written := make(chan string, 1)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			http.NotFound(w, r)
+			return
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		written <- r.Method + " " + string(b)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"Result":"Success"}`)
+	}))
+	defer s.Close()
+	c, err := configcenter.NewConfigCenter(config.Options{
+		ServerURI: s.URL,
+		Labels:    map[string]string{config.LabelApp: "default", config.LabelService: "cart"}})
+	assert.NoError(t, err)
+	defer c.(io.Closer).Close()
+
+	_, err = config.PushConfigsIf(c, map[string]interface{}{"timeout": "2s"}, nil, config.Precondition{Revision: "7"})
+	assert.Equal(t, config.ErrNotSupported, err)
+	_, err = config.DeleteConfigsByKeysIf(c, []string{"timeout"}, nil, config.Precondition{Revision: "7"})
+	assert.Equal(t, config.ErrNotSupported, err)
+	assert.Len(t, written, 0)
 }
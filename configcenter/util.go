@@ -21,6 +21,8 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+
+	"github.com/go-chassis/go-chassis-config/pkg/configcenter"
 )
 
 const (
@@ -33,6 +35,17 @@ var (
 	ErrServiceTooLong = errors.New("exceeded max value for service name")
 )
 
+//GenerateDimensionWithEnvironment generates dimension info "service@app#version" of a service in an environment,
+//environment must be one of configcenter.AllowedEnvironments. it is not part of dimension info,
+//config center isolates environments by X-Environment header and environment query of requests
+func GenerateDimensionWithEnvironment(serviceName, version, appName, environment string) (string, error) {
+	if err := configcenter.ValidateEnvironment(environment); err != nil {
+		return "", err
+	}
+	return GenerateDimension(serviceName, version, appName)
+}
+
+//GenerateDimension generates dimension info "service@app#version"
 func GenerateDimension(serviceName, version, appName string) (string, error) {
 	if appName != "" {
 		serviceName = serviceName + "@" + appName
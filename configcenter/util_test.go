@@ -19,6 +19,7 @@ package configcenter_test
 
 import (
 	"github.com/go-chassis/go-chassis-config/configcenter"
+	pkg "github.com/go-chassis/go-chassis-config/pkg/configcenter"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -30,3 +31,16 @@ func TestGenerateDimension(t *testing.T) {
 	d, _ = configcenter.GenerateDimension("cart", "", "default")
 	assert.Equal(t, "cart@default", d)
 }
+
+func TestGenerateDimensionWithEnvironment(t *testing.T) {
+	d, err := configcenter.GenerateDimensionWithEnvironment("cart", "1.0.0", "default", pkg.EnvironmentProduction)
+	assert.NoError(t, err)
+	assert.Equal(t, "cart@default#1.0.0", d)
+
+	d, err = configcenter.GenerateDimensionWithEnvironment("cart", "", "default", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "cart@default", d)
+
+	_, err = configcenter.GenerateDimensionWithEnvironment("cart", "1.0.0", "default", "staging")
+	assert.IsType(t, &pkg.EnvironmentError{}, err)
+}
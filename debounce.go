@@ -0,0 +1,196 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-mesh/openlogging"
+)
+
+const defaultQuietPeriod = time.Second
+
+// DebounceOptions is the settings of debouncing
+type DebounceOptions struct {
+	// QuietPeriod is the time without changes which ends a window, default is 1s
+	QuietPeriod time.Duration
+	// MaxWait limits the length of a window when changes keep coming, 0 means no limit
+	MaxWait time.Duration
+}
+
+// Debouncer calls flush once after a burst of triggers,
+// a window starts at the first trigger and ends after quiet period or max wait
+type Debouncer struct {
+	opts    DebounceOptions
+	flush   func()
+	trigger chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewDebouncer starts a debouncer
+func NewDebouncer(opts DebounceOptions, flush func()) *Debouncer {
+	if opts.QuietPeriod <= 0 {
+		opts.QuietPeriod = defaultQuietPeriod
+	}
+	d := &Debouncer{
+		opts:    opts,
+		flush:   flush,
+		trigger: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+// Trigger starts a window or extends the current one
+func (d *Debouncer) Trigger() {
+	select {
+	case d.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Debouncer) run() {
+	for {
+		select {
+		case <-d.trigger:
+		case <-d.done:
+			return
+		}
+		if !d.wait() {
+			return
+		}
+		d.flush()
+	}
+}
+
+// wait waits for the end of a window, it returns false if debouncer is closed
+func (d *Debouncer) wait() bool {
+	quiet := time.NewTimer(d.opts.QuietPeriod)
+	defer quiet.Stop()
+	var max <-chan time.Time
+	if d.opts.MaxWait > 0 {
+		t := time.NewTimer(d.opts.MaxWait)
+		defer t.Stop()
+		max = t.C
+	}
+	for {
+		select {
+		case <-d.trigger:
+			if !quiet.Stop() {
+				<-quiet.C
+			}
+			quiet.Reset(d.opts.QuietPeriod)
+		case <-quiet.C:
+			return true
+		case <-max:
+			return true
+		case <-d.done:
+			return false
+		}
+	}
+}
+
+// Close stops the debouncer, changes of the current window are dropped
+func (d *Debouncer) Close() error {
+	d.once.Do(func() {
+		close(d.done)
+	})
+	return nil
+}
+
+// WatchDebounced watches configs and calls f with the configs pulled at the end of each window,
+// so changes of all keys in a window are delivered together, even if callback of the plugin
+// only carries changed keys, like config_center
+func WatchDebounced(c Client, f func(map[string]interface{}), errHandler func(err error), labels map[string]string, opts DebounceOptions) (*Debouncer, error) {
+	if errHandler == nil {
+		errHandler = func(err error) {
+			openlogging.GetLogger().Errorf("debounced watch failed: %s", err)
+		}
+	}
+	d := NewDebouncer(opts, func() {
+		kv, err := c.PullConfigs(labels)
+		if err != nil {
+			errHandler(err)
+			return
+		}
+		f(kv)
+	})
+	err := c.Watch(func(map[string]interface{}) {
+		d.Trigger()
+	}, errHandler, labels)
+	if err != nil {
+		d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// WatchEventsDebounced watches changes like WatchEvents and calls f with events merged by MergeEvents once per window,
+// f is not called if changes of a window cancel each other out
+func WatchEventsDebounced(c Client, f func([]*ChangeEvent), errHandler func(err error), labels map[string]string, opts DebounceOptions) (*Debouncer, error) {
+	var mu sync.Mutex
+	var pending []*ChangeEvent
+	d := NewDebouncer(opts, func() {
+		mu.Lock()
+		events := pending
+		pending = nil
+		mu.Unlock()
+		if len(events) != 0 {
+			f(events)
+		}
+	})
+	err := WatchEvents(c, func(events []*ChangeEvent) {
+		mu.Lock()
+		pending = MergeEvents(pending, events)
+		mu.Unlock()
+		d.Trigger()
+	}, errHandler, labels)
+	if err != nil {
+		d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// MergeEvents merges later events into earlier events, so each key has one event from its first old value
+// to its last new value, for example a created then deleted key has no event. result is sorted by key
+func MergeEvents(earlier, later []*ChangeEvent) []*ChangeEvent {
+	merged := make(map[string]*ChangeEvent, len(earlier)+len(later))
+	for _, e := range earlier {
+		merged[e.Key] = e
+	}
+	for _, e := range later {
+		prev, ok := merged[e.Key]
+		if !ok {
+			merged[e.Key] = e
+			continue
+		}
+		m := *e
+		m.OldValue = prev.OldValue
+		switch {
+		case prev.Action == ActionCreate && e.Action == ActionDelete:
+			delete(merged, e.Key)
+			continue
+		case prev.Action == ActionCreate:
+			m.Action = ActionCreate
+		case prev.Action == ActionDelete && e.Action != ActionDelete:
+			m.Action = ActionUpdate
+		}
+		if m.Action == ActionUpdate && reflect.DeepEqual(m.OldValue, m.NewValue) {
+			delete(merged, e.Key)
+			continue
+		}
+		merged[e.Key] = &m
+	}
+	result := make([]*ChangeEvent, 0, len(merged))
+	for _, e := range merged {
+		result = append(result, e)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
@@ -0,0 +1,59 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchDebounced(t *testing.T) {
+	c := &watchClient{mapReader: mapReader{kv: map[string]interface{}{"a": "0", "b": "0", "c": "0"}}}
+	received := make(chan map[string]interface{}, 3)
+	d, err := config.WatchDebounced(c, func(kv map[string]interface{}) {
+		received <- kv
+	}, nil, nil, config.DebounceOptions{QuietPeriod: 50 * time.Millisecond})
+	assert.NoError(t, err)
+	defer d.Close()
+	//a burst of events each carrying one key, ends with a deletion
+	c.change(map[string]interface{}{"a": "1", "b": "0", "c": "0"}, map[string]interface{}{"a": "1"})
+	c.change(map[string]interface{}{"a": "1", "b": "2", "c": "0"}, map[string]interface{}{"b": "2"})
+	c.change(map[string]interface{}{"a": "1", "b": "2"}, map[string]interface{}{"c": "0"})
+	assert.Equal(t, map[string]interface{}{"a": "1", "b": "2"}, <-received)
+	select {
+	case <-received:
+		t.Fatal("burst is not merged")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	t.Run("max wait ends a window", func(t *testing.T) {
+		d, err := config.WatchDebounced(c, func(kv map[string]interface{}) {
+			received <- kv
+		}, nil, nil, config.DebounceOptions{QuietPeriod: time.Hour, MaxWait: 50 * time.Millisecond})
+		assert.NoError(t, err)
+		defer d.Close()
+		c.change(map[string]interface{}{"a": "4"}, map[string]interface{}{"a": "4"})
+		assert.Equal(t, "4", (<-received)["a"])
+	})
+}
+
+func TestWatchEventsDebounced(t *testing.T) {
+	c := &watchClient{mapReader: mapReader{kv: map[string]interface{}{"a": "1", "b": "1"}}}
+	received := make(chan []*config.ChangeEvent, 1)
+	d, err := config.WatchEventsDebounced(c, func(events []*config.ChangeEvent) {
+		received <- events
+	}, nil, nil, config.DebounceOptions{QuietPeriod: 50 * time.Millisecond})
+	assert.NoError(t, err)
+	defer d.Close()
+	c.f(map[string]interface{}{"a": "2", "b": "1", "c": "1"})
+	c.f(map[string]interface{}{"a": "3", "b": "1"})
+	c.f(map[string]interface{}{"a": "3"})
+	events := <-received
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, config.ActionUpdate, events[0].Action)
+	assert.Equal(t, "1", events[0].OldValue)
+	assert.Equal(t, "3", events[0].NewValue)
+	assert.Equal(t, config.ActionDelete, events[1].Action)
+	assert.Equal(t, "b", events[1].Key)
+}
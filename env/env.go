@@ -0,0 +1,168 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package env contains read only config client plugins of process environment variables
+// and command line flags
+package env
+
+import (
+	"os"
+	"strings"
+
+	"github.com/go-chassis/go-chassis-config"
+)
+
+const (
+	// Name of the Plugin
+	Name = "env"
+	//ParamPrefix is the prefix of environment variables, like APP_, variables without it are ignored
+	ParamPrefix = "prefix"
+	//ParamScope is the name of a label, like serviceName. if it is set,
+	//variables with prefix and the upper case label value, like APP_CART_DB_HOST,
+	//override variables without the label value, like APP_DB_HOST
+	ParamScope = "scope"
+)
+
+// NameMapper converts a variable name without prefix to a config key, it returns false to skip the variable
+type NameMapper func(name string) (string, bool)
+
+// DefaultMapper lowers name and replaces _ with dot, double underscore stands for one underscore,
+// so DB_HOST is db.host and MAX__CONN is max_conn
+func DefaultMapper(name string) (string, bool) {
+	if name == "" {
+		return "", false
+	}
+	name = strings.ToLower(name)
+	parts := strings.Split(name, "__")
+	for i := range parts {
+		parts[i] = strings.Replace(parts[i], "_", ".", -1)
+	}
+	return strings.Join(parts, "_"), true
+}
+
+// Client is read only config client of a flat kv source
+type Client struct {
+	opts config.Options
+	load func(labels map[string]string) map[string]interface{}
+}
+
+// NewClient create environment variables config client with DefaultMapper
+func NewClient(options config.Options) (config.Client, error) {
+	return New(options, DefaultMapper), nil
+}
+
+// New create environment variables config client with a name mapper
+func New(options config.Options, mapper NameMapper) *Client {
+	c := &Client{opts: options}
+	c.load = func(labels map[string]string) map[string]interface{} {
+		return Environ(os.Environ(), c.prefixes(labels), mapper)
+	}
+	return c
+}
+
+// prefixes returns the prefix and the scoped prefix of labels
+func (c *Client) prefixes(labels map[string]string) []string {
+	if len(labels) == 0 {
+		labels = c.opts.Labels
+	}
+	prefix := c.opts.Params[ParamPrefix]
+	prefixes := []string{prefix}
+	if scope := c.opts.Params[ParamScope]; scope != "" && labels[scope] != "" {
+		prefixes = append(prefixes, prefix+strings.ToUpper(labels[scope])+"_")
+	}
+	return prefixes
+}
+
+// Environ converts variables like KEY=value to configs, prefixes are checked in order,
+// so variables with latter prefixes override former ones
+func Environ(environ []string, prefixes []string, mapper NameMapper) map[string]interface{} {
+	kv := make(map[string]interface{})
+	for _, prefix := range prefixes {
+		for _, e := range environ {
+			i := strings.Index(e, "=")
+			if i <= 0 || !strings.HasPrefix(e[:i], prefix) {
+				continue
+			}
+			name := e[len(prefix):i]
+			if isScoped(name, prefix, prefixes) {
+				continue
+			}
+			if k, ok := mapper(name); ok {
+				kv[k] = e[i+1:]
+			}
+		}
+	}
+	return kv
+}
+
+// isScoped reports whether a variable belongs to a longer prefix
+func isScoped(name, prefix string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if len(p) > len(prefix) && strings.HasPrefix(prefix+name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// PullConfigs returns all configs of the source
+func (c *Client) PullConfigs(labels ...map[string]string) (map[string]interface{}, error) {
+	var l map[string]string
+	if len(labels) != 0 {
+		l = labels[0]
+	}
+	return c.load(l), nil
+}
+
+// PullConfig returns one config of the source
+func (c *Client) PullConfig(key, contentType string, labels map[string]string) (interface{}, error) {
+	v, ok := c.load(labels)[key]
+	if !ok {
+		return nil, config.ErrKeyNotExist
+	}
+	return v, nil
+}
+
+// PushConfigs is not supported
+func (c *Client) PushConfigs(items map[string]interface{}, labels map[string]string) (map[string]interface{}, error) {
+	return nil, config.ErrNotSupported
+}
+
+// DeleteConfigsByKeys is not supported
+func (c *Client) DeleteConfigsByKeys(keys []string, labels map[string]string) (map[string]interface{}, error) {
+	return nil, config.ErrNotSupported
+}
+
+// Watch is not supported, the source does not change after process started
+func (c *Client) Watch(f func(map[string]interface{}), errHandler func(err error), labels map[string]string) error {
+	return config.ErrNotSupported
+}
+
+// Capabilities reports that the client is read only
+func (c *Client) Capabilities() []config.Capability {
+	return []config.Capability{config.CapabilityRead}
+}
+
+// Options return settings
+func (c *Client) Options() config.Options {
+	return c.opts
+}
+
+func init() {
+	config.InstallConfigClientPlugin(Name, NewClient, config.CapabilityRead)
+	config.InstallConfigClientPlugin(FlagName, NewFlagClient, config.CapabilityRead)
+}
@@ -0,0 +1,53 @@
+package env_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/go-chassis/go-chassis-config/env"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvClient(t *testing.T) {
+	os.Setenv("TEST_APP_DB_HOST", "127.0.0.1")
+	os.Setenv("TEST_APP_MAX__CONN", "10")
+	os.Setenv("TEST_APP_CART_DB_HOST", "10.0.0.1")
+	defer os.Unsetenv("TEST_APP_DB_HOST")
+	defer os.Unsetenv("TEST_APP_MAX__CONN")
+	defer os.Unsetenv("TEST_APP_CART_DB_HOST")
+
+	c, err := config.NewClient(env.Name, config.Options{
+		Params: map[string]string{env.ParamPrefix: "TEST_APP_", env.ParamScope: config.LabelService},
+	})
+	assert.NoError(t, err)
+	m, err := c.PullConfigs()
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", m["db.host"])
+	assert.Equal(t, "10", m["max_conn"])
+	assert.Equal(t, "10.0.0.1", m["cart.db.host"])
+
+	v, err := c.PullConfig("db.host", "", map[string]string{config.LabelService: "cart"})
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", v)
+	m, err = c.PullConfigs(map[string]string{config.LabelService: "cart"})
+	assert.NoError(t, err)
+	assert.Nil(t, m["cart.db.host"])
+
+	_, err = c.PushConfigs(map[string]interface{}{"a": "b"}, nil)
+	assert.Equal(t, config.ErrNotSupported, err)
+	assert.Equal(t, config.ErrNotSupported, c.Watch(nil, nil, nil))
+	_, ok := config.AsWriter(c)
+	assert.False(t, ok)
+}
+
+func TestParseFlags(t *testing.T) {
+	m := env.ParseFlags([]string{"run", "--config.db.host=127.0.0.1", "-config.db.port", "3306",
+		"--config.debug", "--other", "x", "--", "--config.ignored"}, "config.")
+	assert.Equal(t, map[string]interface{}{"db.host": "127.0.0.1", "db.port": "3306", "debug": "true"}, m)
+
+	c := env.NewFlag(config.Options{}, []string{"--timeout=1s"})
+	v, err := c.PullConfig("timeout", "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "1s", v)
+}
@@ -0,0 +1,81 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package env
+
+import (
+	"os"
+	"strings"
+
+	"github.com/go-chassis/go-chassis-config"
+)
+
+const (
+	//FlagName is the name of command line flags plugin
+	FlagName = "flag"
+	//ParamFlagPrefix is the prefix of flag names, like config., flags without it are ignored
+	ParamFlagPrefix = "flagPrefix"
+)
+
+// NewFlagClient create command line flags config client, it parses os.Args
+func NewFlagClient(options config.Options) (config.Client, error) {
+	return NewFlag(options, os.Args[1:]), nil
+}
+
+// NewFlag create command line flags config client of args
+func NewFlag(options config.Options, args []string) *Client {
+	kv := ParseFlags(args, options.Params[ParamFlagPrefix])
+	return &Client{
+		opts: options,
+		load: func(labels map[string]string) map[string]interface{} {
+			result := make(map[string]interface{}, len(kv))
+			for k, v := range kv {
+				result[k] = v
+			}
+			return result
+		},
+	}
+}
+
+// ParseFlags converts flags like --db.host=127.0.0.1 or --db.host 127.0.0.1 to configs,
+// prefix is removed from keys. a flag without value, like --debug, is "true".
+// parsing stops at the terminator --
+func ParseFlags(args []string, prefix string) map[string]interface{} {
+	kv := make(map[string]interface{})
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		value := "true"
+		if j := strings.Index(name, "="); j != -1 {
+			name, value = name[:j], name[j+1:]
+		} else if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			i++
+			value = args[i]
+		}
+		if name == "" || !strings.HasPrefix(name, prefix) || name == prefix {
+			continue
+		}
+		kv[name[len(prefix):]] = value
+	}
+	return kv
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// actions of change events
+const (
+	ActionCreate = "CREATE"
+	ActionUpdate = "UPDATE"
+	ActionDelete = "DELETE"
+)
+
+// ChangeEvent is a change of one key
+type ChangeEvent struct {
+	Key      string
+	Action   string
+	OldValue interface{}
+	NewValue interface{}
+	// Dimension locates the changed config, it is the dimension info of config center, empty for other plugins
+	Dimension string
+	// Revision is the revision after change if the server reports it
+	Revision  string
+	Timestamp time.Time
+}
+
+// EventWatcher is implemented by clients which deliver changes as events
+type EventWatcher interface {
+	WatchEvents(f func([]*ChangeEvent), errHandler func(err error), labels map[string]string) error
+}
+
+// Diff returns events which change old configs to new configs, sorted by key
+func Diff(old, new map[string]interface{}) []*ChangeEvent {
+	now := time.Now()
+	events := make([]*ChangeEvent, 0)
+	for k, v := range new {
+		ov, ok := old[k]
+		if !ok {
+			events = append(events, &ChangeEvent{Key: k, Action: ActionCreate, NewValue: v, Timestamp: now})
+			continue
+		}
+		if !reflect.DeepEqual(ov, v) {
+			events = append(events, &ChangeEvent{Key: k, Action: ActionUpdate, OldValue: ov, NewValue: v, Timestamp: now})
+		}
+	}
+	for k, v := range old {
+		if _, ok := new[k]; !ok {
+			events = append(events, &ChangeEvent{Key: k, Action: ActionDelete, OldValue: v, Timestamp: now})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Key < events[j].Key
+	})
+	return events
+}
+
+// WatchEvents watches changes of a client as events, it uses EventWatcher if client implements it,
+// or else it watches snapshots and diffs them against a local cache pulled at first.
+// f is not called if nothing changed
+func WatchEvents(c Client, f func([]*ChangeEvent), errHandler func(err error), labels map[string]string) error {
+	if w, ok := c.(EventWatcher); ok {
+		return w.WatchEvents(f, errHandler, labels)
+	}
+	w, ok := AsWatcher(c)
+	if !ok {
+		return ErrNotSupported
+	}
+	cache, err := c.PullConfigs(labels)
+	if err != nil {
+		return err
+	}
+	var mu sync.Mutex
+	return w.Watch(func(kv map[string]interface{}) {
+		mu.Lock()
+		events := Diff(cache, kv)
+		cache = kv
+		mu.Unlock()
+		if len(events) != 0 {
+			f(events)
+		}
+	}, errHandler, labels)
+}
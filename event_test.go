@@ -0,0 +1,40 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	events := config.Diff(map[string]interface{}{"a": 1, "b": 2, "c": 3},
+		map[string]interface{}{"a": 1, "b": 4, "d": 5})
+	assert.Equal(t, 3, len(events))
+	assert.Equal(t, "b", events[0].Key)
+	assert.Equal(t, config.ActionUpdate, events[0].Action)
+	assert.Equal(t, 2, events[0].OldValue)
+	assert.Equal(t, 4, events[0].NewValue)
+	assert.Equal(t, config.ActionDelete, events[1].Action)
+	assert.Equal(t, 3, events[1].OldValue)
+	assert.Equal(t, config.ActionCreate, events[2].Action)
+	assert.Equal(t, 5, events[2].NewValue)
+}
+
+func TestWatchEvents(t *testing.T) {
+	c := &watchClient{mapReader: mapReader{kv: map[string]interface{}{"a": "1"}}}
+	var events []*config.ChangeEvent
+	err := config.WatchEvents(c, func(e []*config.ChangeEvent) {
+		events = append(events, e...)
+	}, nil, nil)
+	assert.NoError(t, err)
+	c.f(map[string]interface{}{"a": "1"})
+	assert.Empty(t, events)
+	c.f(map[string]interface{}{"b": "2"})
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, config.ActionDelete, events[0].Action)
+	assert.Equal(t, config.ActionCreate, events[1].Action)
+
+	err = config.WatchEvents(&readOnlyClient{}, nil, nil, nil)
+	assert.Equal(t, config.ErrNotSupported, err)
+}
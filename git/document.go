@@ -0,0 +1,202 @@
+package git
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/go-chassis/go-chassis-config/serializers"
+	"gopkg.in/yaml.v3"
+)
+
+// edit sets and deletes flat keys in a yaml or json document and keeps its structure,
+// a key like "a.b" is matched against nested mappings, so it updates b under a, or a flat key "a.b".
+// new keys are nested under the deepest existing mapping. comments of yaml documents are kept
+func edit(s string, b []byte, set map[string]interface{}, del []string) ([]byte, error) {
+	if s == serializers.JsonEncoder {
+		return editJSON(b, set, del)
+	}
+	return editYAML(b, set, del)
+}
+
+func sortedKeys(set map[string]interface{}) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// match finds the longest prefix of path which is a key of mapping, n is the length of prefix, 0 means no key matches
+func match(path []string, has func(key string) bool) (key string, n int) {
+	for n = len(path); n > 0; n-- {
+		key = strings.Join(path[:n], ".")
+		if has(key) {
+			return key, n
+		}
+	}
+	return "", 0
+}
+
+func editYAML(b []byte, set map[string]interface{}, del []string) ([]byte, error) {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(b, doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 {
+		doc.Kind = yaml.DocumentNode
+	}
+	if len(doc.Content) == 0 {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	root := doc.Content[0]
+	for _, k := range sortedKeys(set) {
+		value, err := yamlValue(set[k])
+		if err != nil {
+			return nil, err
+		}
+		setYAML(root, strings.Split(k, "."), value)
+	}
+	for _, k := range del {
+		deleteYAML(root, strings.Split(k, "."))
+	}
+	buf := &bytes.Buffer{}
+	e := yaml.NewEncoder(buf)
+	e.SetIndent(2)
+	if err := e.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := e.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// yamlValue converts a value to a yaml node
+func yamlValue(v interface{}) (*yaml.Node, error) {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	n := &yaml.Node{}
+	if err := yaml.Unmarshal(b, n); err != nil {
+		return nil, err
+	}
+	return n.Content[0], nil
+}
+
+// yamlIndex returns the index of value node of key in a mapping node, or -1
+func yamlIndex(m *yaml.Node, key string) int {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func setYAML(m *yaml.Node, path []string, value *yaml.Node) {
+	key, n := match(path, func(k string) bool { return yamlIndex(m, k) >= 0 })
+	if n == 0 {
+		//nest the new key, like the existing keys
+		for i := len(path) - 1; i > 0; i-- {
+			value = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: path[i]}, value}}
+		}
+		m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: path[0]}, value)
+		return
+	}
+	i := yamlIndex(m, key)
+	if n == len(path) {
+		//keep comments of the former value
+		value.HeadComment, value.LineComment, value.FootComment = m.Content[i].HeadComment, m.Content[i].LineComment, m.Content[i].FootComment
+		m.Content[i] = value
+		return
+	}
+	if m.Content[i].Kind == yaml.MappingNode {
+		setYAML(m.Content[i], path[n:], value)
+		return
+	}
+	//the prefix is not a mapping, so the key is kept flat
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: strings.Join(path, ".")}, value)
+}
+
+// deleteYAML removes a key, a mapping emptied by the deletion is removed too
+func deleteYAML(m *yaml.Node, path []string) {
+	key, n := match(path, func(k string) bool { return yamlIndex(m, k) >= 0 })
+	if n == 0 {
+		return
+	}
+	i := yamlIndex(m, key)
+	if n < len(path) {
+		child := m.Content[i]
+		if child.Kind != yaml.MappingNode {
+			return
+		}
+		deleteYAML(child, path[n:])
+		if len(child.Content) != 0 {
+			return
+		}
+	}
+	m.Content = append(m.Content[:i-1], m.Content[i+1:]...)
+}
+
+func editJSON(b []byte, set map[string]interface{}, del []string) ([]byte, error) {
+	doc := make(map[string]interface{})
+	if len(bytes.TrimSpace(b)) != 0 {
+		if err := json.Unmarshal(b, &doc); err != nil {
+			return nil, err
+		}
+	}
+	for _, k := range sortedKeys(set) {
+		setJSON(doc, strings.Split(k, "."), set[k])
+	}
+	for _, k := range del {
+		deleteJSON(doc, strings.Split(k, "."))
+	}
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+func setJSON(m map[string]interface{}, path []string, value interface{}) {
+	key, n := match(path, func(k string) bool { _, ok := m[k]; return ok })
+	if n == 0 {
+		for i := len(path) - 1; i > 0; i-- {
+			value = map[string]interface{}{path[i]: value}
+		}
+		m[path[0]] = value
+		return
+	}
+	if n == len(path) {
+		m[key] = value
+		return
+	}
+	if child, ok := m[key].(map[string]interface{}); ok {
+		setJSON(child, path[n:], value)
+		return
+	}
+	m[strings.Join(path, ".")] = value
+}
+
+func deleteJSON(m map[string]interface{}, path []string) {
+	key, n := match(path, func(k string) bool { _, ok := m[k]; return ok })
+	if n == 0 {
+		return
+	}
+	if n < len(path) {
+		child, ok := m[key].(map[string]interface{})
+		if !ok {
+			return
+		}
+		deleteJSON(child, path[n:])
+		if len(child) != 0 {
+			return
+		}
+	}
+	delete(m, key)
+}
@@ -0,0 +1,485 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package git is the config client plugin which reads config files in a git repository.
+// it requires the git command line
+package git
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/go-chassis/go-chassis-config/pkg/util"
+	"github.com/go-chassis/go-chassis-config/serializers"
+	"github.com/go-mesh/openlogging"
+)
+
+const (
+	// Name of the Plugin
+	Name = "git"
+	//LabelPath is the label of config file path in repository, if it is absent,
+	//path is joined by app, environment, serviceName and version labels with yaml extension
+	LabelPath = "path"
+
+	//ParamRef is the branch or tag to read, default is the default branch of remote
+	ParamRef = "ref"
+	//ParamDir is the local directory of the clone, default is a temp directory
+	ParamDir = "dir"
+	//ParamAuthorName is the author name of commits
+	ParamAuthorName = "authorName"
+	//ParamAuthorEmail is the author email of commits
+	ParamAuthorEmail = "authorEmail"
+	//ParamMessage is the message of commits, default message describes the change
+	ParamMessage = "message"
+
+	defaultAuthorName    = "go-chassis-config"
+	defaultAuthorEmail   = "go-chassis-config@localhost"
+	defaultWatchDuration = 30 * time.Second
+)
+
+// errors
+var (
+	ErrInvalidEP   = errors.New("invalid repository")
+	ErrPathEmpty   = errors.New("config file path can not be empty")
+	ErrUnknownType = errors.New("config file must be yaml or json")
+	ErrInvalidPath = errors.New("config file path must be inside repository")
+	ErrNotBranch   = errors.New("ref is not a branch, configs can not be written")
+)
+
+// Client is git config client implementation
+type Client struct {
+	opts config.Options
+	mu   sync.Mutex
+	r    *repo
+	tmp  bool
+	done chan struct{}
+	once sync.Once
+}
+
+// NewClient clones the repository and create git config client
+func NewClient(options config.Options) (config.Client, error) {
+	if options.ServerURI == "" {
+		return nil, ErrInvalidEP
+	}
+	dir := options.Params[ParamDir]
+	tmp := dir == ""
+	if tmp {
+		var err error
+		if dir, err = ioutil.TempDir("", "go-chassis-config-git"); err != nil {
+			return nil, err
+		}
+	}
+	r, err := open(options.ServerURI, options.Params[ParamRef], dir)
+	if err != nil {
+		return nil, err
+	}
+	openlogging.Info("new git client", openlogging.WithTags(
+		openlogging.Tags{
+			"repo": options.ServerURI,
+			"ref":  r.ref,
+			"dir":  dir,
+		}))
+	return &Client{opts: options, r: r, tmp: tmp, done: make(chan struct{})}, nil
+}
+
+func (c *Client) params(key, defaultValue string) string {
+	if v := c.opts.Params[key]; v != "" {
+		return v
+	}
+	return defaultValue
+}
+
+// Path returns the config file path of labels, relative to repository root
+func (c *Client) Path(labels map[string]string) (string, error) {
+	if len(labels) == 0 {
+		labels = c.opts.Labels
+	}
+	if p := strings.Trim(labels[LabelPath], "/"); p != "" {
+		return clean(p)
+	}
+	elements := make([]string, 0, 4)
+	for _, l := range []string{config.LabelApp, config.LabelEnvironment, config.LabelService, config.LabelVersion} {
+		if labels[l] != "" {
+			elements = append(elements, labels[l])
+		}
+	}
+	if len(elements) == 0 {
+		return "", ErrPathEmpty
+	}
+	return clean(strings.Join(elements, "/") + ".yaml")
+}
+
+// clean cleans a relative path and rejects paths out of repository
+func clean(p string) (string, error) {
+	p = path.Clean(p)
+	if p == "." || p == ".." || strings.HasPrefix(p, "../") || strings.HasPrefix(p, ".git/") {
+		return "", ErrInvalidPath
+	}
+	return p, nil
+}
+
+func serializer(file string) (string, error) {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		return serializers.YamlEncoder, nil
+	case ".json":
+		return serializers.JsonEncoder, nil
+	}
+	return "", ErrUnknownType
+}
+
+// read decodes and flattens a config file, a missing file has no config
+func (c *Client) read(file string) (map[string]interface{}, error) {
+	s, err := serializer(file)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadFile(filepath.Join(c.r.dir, filepath.FromSlash(file)))
+	if os.IsNotExist(err) {
+		return make(map[string]interface{}), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decode(s, b)
+}
+
+func decode(s string, b []byte) (map[string]interface{}, error) {
+	doc := make(map[string]interface{})
+	if err := serializers.Decode(s, b, &doc); err != nil {
+		return nil, err
+	}
+	return util.Flatten(doc), nil
+}
+
+// edit sets and deletes keys in the config file, it returns the edited file and its configs,
+// the structure and comments of the file are kept
+func (c *Client) edit(file string, set map[string]interface{}, del []string) ([]byte, map[string]interface{}, error) {
+	s, err := serializer(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := ioutil.ReadFile(filepath.Join(c.r.dir, filepath.FromSlash(file)))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	if b, err = edit(s, b, set, del); err != nil {
+		return nil, nil, err
+	}
+	kv, err := decode(s, b)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, kv, nil
+}
+
+// write writes the config file, the file is removed if it has no config
+func (c *Client) write(file string, b []byte, kv map[string]interface{}) error {
+	name := filepath.Join(c.r.dir, filepath.FromSlash(file))
+	if len(kv) == 0 {
+		return os.Remove(name)
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, b, 0644)
+}
+
+// PullConfigs reads the config file of labels
+func (c *Client) PullConfigs(labels ...map[string]string) (map[string]interface{}, error) {
+	var l map[string]string
+	if len(labels) != 0 {
+		l = labels[0]
+	}
+	file, err := c.Path(l)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.read(file)
+}
+
+// PullConfig reads one key of the config file of labels
+func (c *Client) PullConfig(key, contentType string, labels map[string]string) (interface{}, error) {
+	kv, err := c.PullConfigs(labels)
+	if err != nil {
+		return nil, err
+	}
+	v, ok := kv[key]
+	if !ok {
+		return nil, config.ErrKeyNotExist
+	}
+	return v, nil
+}
+
+// PushConfigs writes items into the config file and pushes a commit
+func (c *Client) PushConfigs(items map[string]interface{}, labels map[string]string) (map[string]interface{}, error) {
+	if len(items) == 0 {
+		return nil, errors.New("data is empty, nothing to push")
+	}
+	return c.update(labels, "update ", items, nil)
+}
+
+// DeleteConfigsByKeys removes keys from the config file and pushes a commit
+func (c *Client) DeleteConfigsByKeys(keys []string, labels map[string]string) (map[string]interface{}, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no key need to delete, please check keys")
+	}
+	return c.update(labels, "delete keys from ", nil, keys)
+}
+
+// update sets and deletes keys of the config file of labels, then commits and pushes it if configs changed
+func (c *Client) update(labels map[string]string, action string, set map[string]interface{}, del []string) (map[string]interface{}, error) {
+	if !c.r.branch {
+		return nil, ErrNotBranch
+	}
+	file, err := c.Path(labels)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.r.sync(); err != nil {
+		return nil, err
+	}
+	before, err := c.read(file)
+	if err != nil {
+		return nil, err
+	}
+	b, after, err := c.edit(file, set, del)
+	if err != nil {
+		return nil, err
+	}
+	if reflect.DeepEqual(before, after) {
+		head, err := c.r.head()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"path": file, "commit": head}, nil
+	}
+	if err := c.write(file, b, after); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	commit, err := c.r.commit(file,
+		c.params(ParamAuthorName, defaultAuthorName),
+		c.params(ParamAuthorEmail, defaultAuthorEmail),
+		c.params(ParamMessage, action+file))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"path": file, "commit": commit}, nil
+}
+
+// readCommit returns configs of a file in a commit, a missing file has no config
+func (c *Client) readCommit(commit, file string) (map[string]interface{}, error) {
+	s, err := serializer(file)
+	if err != nil {
+		return nil, err
+	}
+	b, ok, err := c.r.show(commit, file)
+	if err != nil || !ok {
+		return make(map[string]interface{}), err
+	}
+	return decode(s, b)
+}
+
+func newRevision(key string, commit commitInfo, kv map[string]interface{}) config.Revision {
+	return config.Revision{
+		Key:       key,
+		Revision:  commit.id,
+		Value:     kv[key],
+		Author:    commit.author,
+		Timestamp: commit.time,
+	}
+}
+
+// History returns commits of the config file which changed the key, latest first
+func (c *Client) History(key string, labels map[string]string) ([]config.Revision, error) {
+	file, err := c.Path(labels)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.r.sync(); err != nil {
+		return nil, err
+	}
+	commits, err := c.r.log(file)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]config.Revision, 0)
+	for i := len(commits) - 1; i >= 0; i-- {
+		kv, err := c.readCommit(commits[i].id, file)
+		if err != nil {
+			return nil, err
+		}
+		r := newRevision(key, commits[i], kv)
+		if len(result) == 0 && r.Value == nil {
+			//key is not created yet
+			continue
+		}
+		if len(result) != 0 && reflect.DeepEqual(result[len(result)-1].Value, r.Value) {
+			continue
+		}
+		result = append(result, r)
+	}
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result, nil
+}
+
+// GetRevision returns the value of key in the config file of a commit, revision can be any git revision like a tag
+func (c *Client) GetRevision(key, revision string, labels map[string]string) (*config.Revision, error) {
+	file, err := c.Path(labels)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	commit, ok, err := c.r.commitOf(revision)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if _, err := c.r.sync(); err != nil {
+			return nil, err
+		}
+		if commit, ok, err = c.r.commitOf(revision); err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, config.ErrRevisionNotExist
+		}
+	}
+	kv, err := c.readCommit(commit.id, file)
+	if err != nil {
+		return nil, err
+	}
+	r := newRevision(key, commit, kv)
+	return &r, nil
+}
+
+// Rollback pushes a commit which sets key to its value in a revision, other keys are unchanged
+func (c *Client) Rollback(key, revision string, labels map[string]string) (map[string]interface{}, error) {
+	r, err := c.GetRevision(key, revision, labels)
+	if err != nil {
+		return nil, err
+	}
+	action := "rollback " + key + " to " + revision + " in "
+	if r.Value == nil {
+		return c.update(labels, action, nil, []string{key})
+	}
+	return c.update(labels, action, map[string]interface{}{key: r.Value}, nil)
+}
+
+// Watch fetches the ref periodically,
+// after a new commit changed the config file, it calls f with the latest configs
+func (c *Client) Watch(f func(map[string]interface{}), errHandler func(err error), labels map[string]string) error {
+	file, err := c.Path(labels)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	last, err := c.read(file)
+	if err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	since, err := c.r.head()
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	d := c.opts.WatchDuration
+	if d <= 0 {
+		d = defaultWatchDuration
+	}
+	go func() {
+		for range util.Tick(d, c.done) {
+			kv, head, err := c.fetch(file, since)
+			if err != nil {
+				errHandler(err)
+				continue
+			}
+			since = head
+			if kv == nil || reflect.DeepEqual(kv, last) {
+				continue
+			}
+			last = kv
+			f(kv)
+		}
+	}()
+	return nil
+}
+
+// fetch syncs the clone and returns the new head,
+// configs of file are returned only if the file is changed by commits since the given one
+func (c *Client) fetch(file, since string) (map[string]interface{}, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.r.sync(); err != nil {
+		return nil, since, err
+	}
+	head, err := c.r.head()
+	if err != nil || head == since {
+		return nil, since, err
+	}
+	files, err := c.r.changed(since, head)
+	if err != nil {
+		return nil, since, err
+	}
+	for _, f := range files {
+		if f == file {
+			kv, err := c.read(file)
+			return kv, head, err
+		}
+	}
+	return nil, head, nil
+}
+
+// Close stops watches, the clone is removed if it is in a temporary directory
+func (c *Client) Close() error {
+	c.once.Do(func() {
+		close(c.done)
+	})
+	if !c.tmp {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return os.RemoveAll(c.r.dir)
+}
+
+// Options return settings
+func (c *Client) Options() config.Options {
+	return c.opts
+}
+
+func init() {
+	config.InstallConfigClientPlugin(Name, NewClient, config.CapabilityRead, config.CapabilityWrite, config.CapabilityWatch, config.CapabilityHistory)
+}
@@ -0,0 +1,218 @@
+package git_test
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/go-chassis/go-chassis-config/git"
+	"github.com/stretchr/testify/assert"
+)
+
+func run(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@test"}, args...)...)
+	out, err := cmd.CombinedOutput()
+	assert.NoError(t, err, string(out))
+}
+
+// origin creates a bare repository with a config file on master
+func origin(t *testing.T, root string) string {
+	bare := filepath.Join(root, "origin.git")
+	work := filepath.Join(root, "work")
+	assert.NoError(t, os.MkdirAll(bare, 0755))
+	run(t, bare, "init", "--quiet", "--bare")
+	run(t, root, "clone", "--quiet", bare, work)
+	assert.NoError(t, os.MkdirAll(filepath.Join(work, "mall"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(work, "mall", "cart.yaml"), []byte("db:\n  host: 127.0.0.1\n"), 0644))
+	run(t, work, "add", "--all")
+	run(t, work, "commit", "--quiet", "-m", "init")
+	run(t, work, "push", "--quiet", "origin", "HEAD:refs/heads/master")
+	return bare
+}
+
+func TestClient(t *testing.T) {
+	root, err := ioutil.TempDir("", "git-plugin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+	bare := origin(t, root)
+
+	c, err := config.NewClient(git.Name, config.Options{
+		ServerURI:     "file://" + bare,
+		WatchDuration: 50 * time.Millisecond,
+		Labels:        map[string]string{config.LabelApp: "mall", config.LabelService: "cart"},
+		Params: map[string]string{
+			git.ParamRef:        "master",
+			git.ParamDir:        filepath.Join(root, "clone"),
+			git.ParamAuthorName: "bot",
+		},
+	})
+	assert.NoError(t, err)
+	defer c.(io.Closer).Close()
+
+	m, err := c.PullConfigs()
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", m["db.host"])
+
+	events := make(chan map[string]interface{}, 10)
+	err = c.Watch(func(m map[string]interface{}) {
+		events <- m
+	}, func(err error) {
+		t.Log(err)
+	}, nil)
+	assert.NoError(t, err)
+
+	//another user changes the file
+	work := filepath.Join(root, "work")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(work, "mall", "cart.yaml"), []byte("db:\n  host: 10.0.0.1\n"), 0644))
+	run(t, work, "commit", "--quiet", "-am", "change host")
+	run(t, work, "push", "--quiet", "origin", "HEAD:refs/heads/master")
+	select {
+	case m := <-events:
+		assert.Equal(t, "10.0.0.1", m["db.host"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+	}
+
+	r, err := c.PushConfigs(map[string]interface{}{"db.port": 3306}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "mall/cart.yaml", r["path"])
+	_, err = c.DeleteConfigsByKeys([]string{"db.host"}, nil)
+	assert.NoError(t, err)
+
+	run(t, work, "pull", "--quiet", "origin", "master")
+	b, err := ioutil.ReadFile(filepath.Join(work, "mall", "cart.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "db:\n  port: 3306\n", string(b))
+	cmd := exec.Command("git", "-C", work, "log", "-1", "--format=%an %s")
+	out, err := cmd.Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "bot delete keys from mall/cart.yaml\n", string(out))
+
+	v, err := c.PullConfig("db.port", "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3306, v)
+	_, err = c.PullConfig("db.host", "", nil)
+	assert.Equal(t, config.ErrKeyNotExist, err)
+}
+
+func TestClient_History(t *testing.T) {
+	root, err := ioutil.TempDir("", "git-plugin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+	bare := origin(t, root)
+	work := filepath.Join(root, "work")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(work, "mall", "cart.yaml"), []byte("db:\n  host: 10.0.0.1\n"), 0644))
+	run(t, work, "commit", "--quiet", "-am", "change host")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(work, "mall", "cart.yaml"), []byte("db:\n  host: 10.0.0.1\n  port: 3306\n"), 0644))
+	run(t, work, "commit", "--quiet", "-am", "add port")
+	run(t, work, "push", "--quiet", "origin", "HEAD:refs/heads/master")
+
+	c, err := config.NewClient(git.Name, config.Options{
+		ServerURI: "file://" + bare,
+		Labels:    map[string]string{config.LabelApp: "mall", config.LabelService: "cart"},
+		Params:    map[string]string{git.ParamRef: "master"},
+	})
+	assert.NoError(t, err)
+	defer c.(io.Closer).Close()
+
+	h, ok := config.AsHistorian(c)
+	assert.True(t, ok)
+	revisions, err := h.History("db.host", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(revisions))
+	assert.Equal(t, "10.0.0.1", revisions[0].Value)
+	assert.Equal(t, "127.0.0.1", revisions[1].Value)
+	assert.Equal(t, "test", revisions[1].Author)
+
+	r, err := config.GetRevision(c, "db.port", revisions[1].Revision, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, r.Value)
+	_, err = config.GetRevision(c, "db.port", "no-such-tag", nil)
+	assert.Equal(t, config.ErrRevisionNotExist, err)
+
+	_, err = config.Rollback(c, "db.host", revisions[1].Revision, nil)
+	assert.NoError(t, err)
+	kv, err := c.PullConfigs()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"db.host": "127.0.0.1", "db.port": 3306}, kv)
+}
+
+func TestClient_Path(t *testing.T) {
+	root, err := ioutil.TempDir("", "git-plugin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+	bare := origin(t, root)
+	run(t, filepath.Join(root, "work"), "tag", "v1")
+	run(t, filepath.Join(root, "work"), "push", "--quiet", "origin", "v1")
+
+	c, err := git.NewClient(config.Options{
+		ServerURI: "file://" + bare,
+		Params:    map[string]string{git.ParamRef: "v1", git.ParamDir: filepath.Join(root, "clone")},
+	})
+	assert.NoError(t, err)
+	defer c.(io.Closer).Close()
+
+	for _, p := range []string{"../outside.yaml", "mall/../../outside.yaml", ".git/config.yaml"} {
+		_, err = c.PullConfigs(map[string]string{git.LabelPath: p})
+		assert.Equal(t, git.ErrInvalidPath, err, p)
+	}
+	_, err = c.PullConfigs(map[string]string{config.LabelApp: "..", config.LabelService: "outside"})
+	assert.Equal(t, git.ErrInvalidPath, err)
+
+	m, err := c.PullConfigs(map[string]string{git.LabelPath: "mall/cart.yaml"})
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", m["db.host"])
+	_, err = c.PushConfigs(map[string]interface{}{"db.port": 3306}, map[string]string{git.LabelPath: "mall/cart.yaml"})
+	assert.Equal(t, git.ErrNotBranch, err)
+}
+
+func TestClient_KeepStructure(t *testing.T) {
+	root, err := ioutil.TempDir("", "git-plugin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+	bare := origin(t, root)
+	work := filepath.Join(root, "work")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(work, "mall", "cart.yaml"), []byte(`# database of cart
+db:
+  host: 127.0.0.1 # primary
+  user: root
+log.level: info
+`), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(work, "mall", "cart.json"), []byte(`{"db": {"host": "127.0.0.1", "user": "root"}}`), 0644))
+	run(t, work, "add", "--all")
+	run(t, work, "commit", "--quiet", "-m", "nested")
+	run(t, work, "push", "--quiet", "origin", "HEAD:refs/heads/master")
+
+	c, err := git.NewClient(config.Options{
+		ServerURI: "file://" + bare,
+		Params:    map[string]string{git.ParamRef: "master", git.ParamDir: filepath.Join(root, "clone")},
+	})
+	assert.NoError(t, err)
+	defer c.(io.Closer).Close()
+
+	for _, file := range []string{"mall/cart.yaml", "mall/cart.json"} {
+		labels := map[string]string{git.LabelPath: file}
+		_, err = c.PushConfigs(map[string]interface{}{"db.host": "10.0.0.1", "db.pool.size": 10, "log.level": "debug"}, labels)
+		assert.NoError(t, err)
+		_, err = c.DeleteConfigsByKeys([]string{"db.user"}, labels)
+		assert.NoError(t, err)
+	}
+	run(t, work, "pull", "--quiet", "origin", "master")
+	b, err := ioutil.ReadFile(filepath.Join(work, "mall", "cart.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, `# database of cart
+db:
+  host: 10.0.0.1 # primary
+  pool:
+    size: 10
+log.level: debug
+`, string(b))
+	b, err = ioutil.ReadFile(filepath.Join(work, "mall", "cart.json"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"db": {"host": "10.0.0.1", "pool": {"size": 10}}, "log": {"level": "debug"}}`, string(b))
+}
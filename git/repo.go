@@ -0,0 +1,197 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// repo is a local clone operated by git command line
+type repo struct {
+	dir string
+	ref string
+	//branch is false if ref is a tag or commit, commits can only be pushed to a branch
+	branch bool
+}
+
+func (r *repo) git(args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", r.dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %s, %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// open clones the remote repository into dir, it reuses the clone if dir is already a repository
+func open(remote, ref, dir string) (*repo, error) {
+	r := &repo{dir: dir, ref: ref}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+		args := []string{"clone", "--quiet"}
+		if ref != "" {
+			args = append(args, "--branch", ref)
+		}
+		if _, err := r.git(append(args, remote, ".")...); err != nil {
+			return nil, err
+		}
+	}
+	if r.ref == "" {
+		branch, err := r.git("rev-parse", "--abbrev-ref", "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		r.ref = branch
+	}
+	if _, err := r.git("ls-remote", "--exit-code", "--heads", "origin", r.ref); err == nil {
+		r.branch = true
+	}
+	if _, err := r.sync(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// head returns the commit id of HEAD
+func (r *repo) head() (string, error) {
+	return r.git("rev-parse", "HEAD")
+}
+
+// sync fetches the ref and resets work tree to it, it returns the commit id before sync
+func (r *repo) sync() (string, error) {
+	old, err := r.head()
+	if err != nil {
+		return "", err
+	}
+	if _, err := r.git("fetch", "--quiet", "--tags", "origin", r.ref); err != nil {
+		return "", err
+	}
+	if _, err := r.git("reset", "--quiet", "--hard", "FETCH_HEAD"); err != nil {
+		return "", err
+	}
+	return old, nil
+}
+
+// changed returns files changed between two commits
+func (r *repo) changed(from, to string) ([]string, error) {
+	out, err := r.git("diff", "--name-only", from, to)
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// commit commits a file and pushes it to the ref
+func (r *repo) commit(file, name, email, message string) (string, error) {
+	if _, err := r.git("add", "--all", "--", file); err != nil {
+		return "", err
+	}
+	if _, err := r.git("-c", "user.name="+name, "-c", "user.email="+email,
+		"commit", "--quiet", "-m", message, "--", file); err != nil {
+		return "", err
+	}
+	if _, err := r.git("push", "--quiet", "origin", "HEAD:refs/heads/"+r.ref); err != nil {
+		//remote moved, drop the local commit
+		if _, e := r.sync(); e != nil {
+			return "", e
+		}
+		return "", err
+	}
+	return r.head()
+}
+
+// commitInfo describes a commit
+type commitInfo struct {
+	id     string
+	author string
+	time   time.Time
+}
+
+const logFormat = "--format=%H%x09%an%x09%at"
+
+func parseCommits(out string) ([]commitInfo, error) {
+	commits := make([]commitInfo, 0)
+	if out == "" {
+		return commits, nil
+	}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected git log: %s", line)
+		}
+		sec, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, commitInfo{id: fields[0], author: fields[1], time: time.Unix(sec, 0)})
+	}
+	return commits, nil
+}
+
+// log returns commits which changed a file, latest first
+func (r *repo) log(file string) ([]commitInfo, error) {
+	out, err := r.git("log", logFormat, "--", file)
+	if err != nil {
+		return nil, err
+	}
+	return parseCommits(out)
+}
+
+// commitOf returns the commit of a revision, ok is false if the revision does not exist
+func (r *repo) commitOf(rev string) (commitInfo, bool, error) {
+	if _, err := r.git("rev-parse", "--verify", "--quiet", rev+"^{commit}"); err != nil {
+		return commitInfo{}, false, nil
+	}
+	out, err := r.git("log", "-1", logFormat, rev)
+	if err != nil {
+		return commitInfo{}, false, err
+	}
+	commits, err := parseCommits(out)
+	if err != nil || len(commits) == 0 {
+		return commitInfo{}, false, err
+	}
+	return commits[0], true, nil
+}
+
+// show returns content of a file in a commit, ok is false if the file does not exist in the commit
+func (r *repo) show(commit, file string) ([]byte, bool, error) {
+	out, err := r.git("ls-tree", "--name-only", commit, "--", file)
+	if err != nil || out == "" {
+		return nil, false, err
+	}
+	out, err = r.git("show", commit+":"+file)
+	if err != nil {
+		return nil, false, err
+	}
+	return []byte(out), true, nil
+}
@@ -1,10 +1,16 @@
 module github.com/go-chassis/go-chassis-config
 
 require (
+	github.com/alicebob/miniredis/v2 v2.30.0
 	github.com/go-chassis/foundation v0.1.0
 	github.com/go-mesh/openlogging v1.0.1
+	github.com/go-zookeeper/zk v1.0.4
+	github.com/gomodule/redigo v1.8.9
 	github.com/gorilla/websocket v1.4.0
-	github.com/stretchr/testify v1.3.0
+	github.com/mattn/go-sqlite3 v1.14.16
+	github.com/stretchr/testify v1.7.0
+	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 )
 
 go 1.13
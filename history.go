@@ -0,0 +1,57 @@
+package config
+
+import "errors"
+
+// ErrRevisionNotExist means the revision of a config does not exist
+var ErrRevisionNotExist = errors.New("revision does not exist")
+
+// Rollbacker fetches a revision of a config and restores it
+type Rollbacker interface {
+	Historian
+	//GetRevision returns a revision of a config, value of revision is nil if the key does not exist in it
+	GetRevision(key, revision string, labels map[string]string) (*Revision, error)
+	//Rollback writes the value of a revision as the latest value, the key is deleted if it does not exist in the revision
+	Rollback(key, revision string, labels map[string]string) (map[string]interface{}, error)
+}
+
+// GetRevision returns a revision of a config, it uses Rollbacker if client implements it,
+// or else it finds the revision in history
+func GetRevision(c interface{}, key, revision string, labels map[string]string) (*Revision, error) {
+	if r, ok := c.(Rollbacker); ok && Supports(c, CapabilityHistory) {
+		return r.GetRevision(key, revision, labels)
+	}
+	h, ok := AsHistorian(c)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	revisions, err := h.History(key, labels)
+	if err != nil {
+		return nil, err
+	}
+	for i := range revisions {
+		if revisions[i].Revision == revision {
+			return &revisions[i], nil
+		}
+	}
+	return nil, ErrRevisionNotExist
+}
+
+// Rollback restores a revision of a config, it uses Rollbacker if client implements it,
+// or else it finds the revision in history and pushes its value
+func Rollback(c interface{}, key, revision string, labels map[string]string) (map[string]interface{}, error) {
+	if r, ok := c.(Rollbacker); ok && Supports(c, CapabilityHistory) {
+		return r.Rollback(key, revision, labels)
+	}
+	w, ok := AsWriter(c)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	r, err := GetRevision(c, key, revision, labels)
+	if err != nil {
+		return nil, err
+	}
+	if r.Value == nil {
+		return w.DeleteConfigsByKeys([]string{key}, labels)
+	}
+	return w.PushConfigs(map[string]interface{}{key: r.Value}, labels)
+}
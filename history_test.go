@@ -0,0 +1,47 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/stretchr/testify/assert"
+)
+
+type historyClient struct {
+	config.Client
+	pushed  map[string]interface{}
+	deleted []string
+}
+
+func (c *historyClient) History(key string, labels map[string]string) ([]config.Revision, error) {
+	return []config.Revision{{Key: key, Revision: "2"}, {Key: key, Revision: "1", Value: "a"}}, nil
+}
+
+func (c *historyClient) PushConfigs(items map[string]interface{}, labels map[string]string) (map[string]interface{}, error) {
+	c.pushed = items
+	return nil, nil
+}
+
+func (c *historyClient) DeleteConfigsByKeys(keys []string, labels map[string]string) (map[string]interface{}, error) {
+	c.deleted = keys
+	return nil, nil
+}
+
+func TestRollback(t *testing.T) {
+	c := &historyClient{}
+	r, err := config.GetRevision(c, "k", "1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", r.Value)
+	_, err = config.GetRevision(c, "k", "3", nil)
+	assert.Equal(t, config.ErrRevisionNotExist, err)
+
+	_, err = config.Rollback(c, "k", "1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"k": "a"}, c.pushed)
+	_, err = config.Rollback(c, "k", "2", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k"}, c.deleted)
+
+	_, err = config.Rollback(&readOnlyClient{}, "k", "1", nil)
+	assert.Equal(t, config.ErrNotSupported, err)
+}
@@ -0,0 +1,273 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package http is the read only config client plugin of json or yaml documents served by a web server.
+// requests are signed by httpclient.SignRequest, the same as config center client
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-chassis/foundation/httpclient"
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/go-chassis/go-chassis-config/pkg/util"
+	"github.com/go-chassis/go-chassis-config/serializers"
+	"github.com/go-mesh/openlogging"
+)
+
+const (
+	// Name of the Plugin
+	Name = "http"
+
+	defaultWatchDuration = 30 * time.Second
+)
+
+// errors
+var (
+	ErrInvalidEP      = errors.New("invalid endpoint")
+	ErrUnknownType    = errors.New("document must be json or yaml")
+	placeholderRegexp = regexp.MustCompile(`\{([^{}]+)\}`)
+)
+
+// Client is http config client implementation
+type Client struct {
+	opts config.Options
+	c    *httpclient.Requests
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Document is a fetched config document
+type Document struct {
+	Configs      map[string]interface{}
+	ETag         string
+	LastModified string
+}
+
+// NewClient create http config client, ServerURI is the document url,
+// it can contain label placeholders like https://127.0.0.1/config/{app}/{serviceName}.yaml
+func NewClient(options config.Options) (config.Client, error) {
+	if options.ServerURI == "" {
+		return nil, ErrInvalidEP
+	}
+	hc, err := httpclient.New(&httpclient.Options{
+		SSLEnabled: options.EnableSSL,
+		TLSConfig:  options.TLSConfig,
+		Compressed: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	openlogging.Info("new http client", openlogging.WithTags(
+		openlogging.Tags{
+			"url": options.ServerURI,
+			"ssl": options.EnableSSL,
+		}))
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Client{opts: options, c: hc, ctx: ctx, cancel: cancel}, nil
+}
+
+// URL returns the document url of labels
+func (c *Client) URL(labels map[string]string) (string, error) {
+	if len(labels) == 0 {
+		labels = c.opts.Labels
+	}
+	var err error
+	u := placeholderRegexp.ReplaceAllStringFunc(c.opts.ServerURI, func(s string) string {
+		name := s[1 : len(s)-1]
+		v, ok := labels[name]
+		if !ok || v == "" {
+			err = fmt.Errorf("label [%s] is required by url", name)
+			return s
+		}
+		return url.PathEscape(v)
+	})
+	return u, err
+}
+
+// serializer decides serializer by content type, url extension is used if content type is unknown
+func serializer(contentType, rawURL string) (string, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == serializers.JsonEncoder || strings.HasSuffix(mediaType, "+json"):
+		return serializers.JsonEncoder, nil
+	case strings.HasSuffix(mediaType, "yaml"):
+		return serializers.YamlEncoder, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	switch strings.ToLower(path.Ext(u.Path)) {
+	case ".json":
+		return serializers.JsonEncoder, nil
+	case ".yaml", ".yml":
+		return serializers.YamlEncoder, nil
+	}
+	return "", ErrUnknownType
+}
+
+// Fetch gets and decodes the document, if the document does not change since last,
+// it returns nil document. last can be nil
+func (c *Client) Fetch(rawURL string, last *Document) (*Document, error) {
+	headers := make(http.Header)
+	if last != nil {
+		if last.ETag != "" {
+			headers.Set("If-None-Match", last.ETag)
+		}
+		if last.LastModified != "" {
+			headers.Set("If-Modified-Since", last.LastModified)
+		}
+	}
+	resp, err := c.c.Get(c.ctx, rawURL, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get %s failed, statusCode: %d, resp body: %s", rawURL, resp.StatusCode, body)
+	}
+	s, err := serializer(resp.Header.Get("Content-Type"), rawURL)
+	if err != nil {
+		return nil, err
+	}
+	doc := make(map[string]interface{})
+	if err := serializers.Decode(s, body, &doc); err != nil {
+		return nil, err
+	}
+	return &Document{
+		Configs:      util.Flatten(doc),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
+
+// PullConfigs returns flattened configs of the document
+func (c *Client) PullConfigs(labels ...map[string]string) (map[string]interface{}, error) {
+	var l map[string]string
+	if len(labels) != 0 {
+		l = labels[0]
+	}
+	u, err := c.URL(l)
+	if err != nil {
+		return nil, err
+	}
+	d, err := c.Fetch(u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return d.Configs, nil
+}
+
+// PullConfig returns one config of the document
+func (c *Client) PullConfig(key, contentType string, labels map[string]string) (interface{}, error) {
+	kv, err := c.PullConfigs(labels)
+	if err != nil {
+		return nil, err
+	}
+	v, ok := kv[key]
+	if !ok {
+		return nil, config.ErrKeyNotExist
+	}
+	return v, nil
+}
+
+// PushConfigs is not supported
+func (c *Client) PushConfigs(items map[string]interface{}, labels map[string]string) (map[string]interface{}, error) {
+	return nil, config.ErrNotSupported
+}
+
+// DeleteConfigsByKeys is not supported
+func (c *Client) DeleteConfigsByKeys(keys []string, labels map[string]string) (map[string]interface{}, error) {
+	return nil, config.ErrNotSupported
+}
+
+// Watch polls the document with conditional requests, it calls f with the latest configs after they changed
+func (c *Client) Watch(f func(map[string]interface{}), errHandler func(err error), labels map[string]string) error {
+	u, err := c.URL(labels)
+	if err != nil {
+		return err
+	}
+	last, err := c.Fetch(u, nil)
+	if err != nil {
+		return err
+	}
+	d := c.opts.WatchDuration
+	if d <= 0 {
+		d = defaultWatchDuration
+	}
+	go func() {
+		for range util.Tick(d, c.ctx.Done()) {
+			doc, err := c.Fetch(u, last)
+			if err != nil {
+				if c.ctx.Err() != nil {
+					return
+				}
+				errHandler(err)
+				continue
+			}
+			if doc == nil {
+				continue
+			}
+			changed := !reflect.DeepEqual(doc.Configs, last.Configs)
+			last = doc
+			if changed {
+				f(doc.Configs)
+			}
+		}
+	}()
+	return nil
+}
+
+// Close stops watches and cancels requests in flight
+func (c *Client) Close() error {
+	c.cancel()
+	return nil
+}
+
+// Capabilities reports that the client is read only
+func (c *Client) Capabilities() []config.Capability {
+	return []config.Capability{config.CapabilityRead, config.CapabilityWatch}
+}
+
+// Options return settings
+func (c *Client) Options() config.Options {
+	return c.opts
+}
+
+func init() {
+	config.InstallConfigClientPlugin(Name, NewClient, config.CapabilityRead, config.CapabilityWatch)
+}
@@ -0,0 +1,78 @@
+package http_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chassis/foundation/httpclient"
+	"github.com/go-chassis/go-chassis-config"
+	confighttp "github.com/go-chassis/go-chassis-config/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient(t *testing.T) {
+	var mu sync.Mutex
+	doc, etag := "db:\n  host: 127.0.0.1\n", `"v1"`
+	var notModified int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Sign") != "signed" || r.URL.Path != "/config/mall/cart" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("If-None-Match") == etag {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-yaml; charset=utf-8")
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(doc))
+	}))
+	defer ts.Close()
+	httpclient.SignRequest = func(r *http.Request) error {
+		r.Header.Set("X-Sign", "signed")
+		return nil
+	}
+	defer func() { httpclient.SignRequest = nil }()
+
+	c, err := config.NewClient(confighttp.Name, config.Options{
+		ServerURI:     ts.URL + "/config/{app}/{serviceName}",
+		WatchDuration: 50 * time.Millisecond,
+		Labels:        map[string]string{config.LabelApp: "mall", config.LabelService: "cart"},
+	})
+	assert.NoError(t, err)
+	defer c.(io.Closer).Close()
+	_, err = c.(*confighttp.Client).URL(map[string]string{config.LabelApp: "mall"})
+	assert.Error(t, err)
+
+	v, err := c.PullConfig("db.host", "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", v)
+
+	events := make(chan map[string]interface{}, 10)
+	err = c.Watch(func(m map[string]interface{}) {
+		events <- m
+	}, func(err error) {
+		t.Log(err)
+	}, nil)
+	assert.NoError(t, err)
+	time.Sleep(200 * time.Millisecond)
+	assert.NotZero(t, atomic.LoadInt32(&notModified))
+
+	mu.Lock()
+	doc, etag = `{"db":{"host":"10.0.0.1"}}`, `"v2"`
+	mu.Unlock()
+	select {
+	case m := <-events:
+		assert.Equal(t, "10.0.0.1", m["db.host"])
+	case <-time.After(3 * time.Second):
+		t.Fatal("no event received")
+	}
+}
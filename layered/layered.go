@@ -0,0 +1,214 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package layered combines several config clients into one client,
+// like defaults of a file, config center and overrides of environment variables
+package layered
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/go-mesh/openlogging"
+)
+
+// errors
+var (
+	ErrNoLayer          = errors.New("layers can not be empty")
+	ErrReadOnly         = errors.New("layered client has no writable layer")
+	ErrWritableNotLayer = errors.New("writable client must be one of layers")
+)
+
+// Client merges configs of layers, latter layers override former ones
+type Client struct {
+	layers   []config.Client
+	writable config.Client
+}
+
+// New create layered client, layers are in precedence order, latter layers override former ones.
+// pushes and deletes go to writable, it must be one of layers, nil means the client is read only
+func New(layers []config.Client, writable config.Client) (*Client, error) {
+	if len(layers) == 0 {
+		return nil, ErrNoLayer
+	}
+	if writable != nil {
+		found := false
+		for _, l := range layers {
+			if l == writable {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, ErrWritableNotLayer
+		}
+	}
+	return &Client{layers: layers, writable: writable}, nil
+}
+
+// merge returns the effective configs of snapshots of layers
+func merge(snapshots []map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{})
+	for _, s := range snapshots {
+		for k, v := range s {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// PullConfigs pulls configs of all layers and merges them
+func (c *Client) PullConfigs(labels ...map[string]string) (map[string]interface{}, error) {
+	snapshots := make([]map[string]interface{}, len(c.layers))
+	for i, l := range c.layers {
+		m, err := l.PullConfigs(labels...)
+		if err != nil {
+			return nil, err
+		}
+		snapshots[i] = m
+	}
+	return merge(snapshots), nil
+}
+
+// PullConfig returns the value of the first layer which has the key, from the highest precedence
+func (c *Client) PullConfig(key, contentType string, labels map[string]string) (interface{}, error) {
+	for i := len(c.layers) - 1; i >= 0; i-- {
+		v, err := c.layers[i].PullConfig(key, contentType, labels)
+		if err == config.ErrKeyNotExist || err == nil && v == nil {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	return nil, config.ErrKeyNotExist
+}
+
+// PushConfigs pushes configs to the writable layer
+func (c *Client) PushConfigs(items map[string]interface{}, labels map[string]string) (map[string]interface{}, error) {
+	if c.writable == nil {
+		return nil, ErrReadOnly
+	}
+	return c.writable.PushConfigs(items, labels)
+}
+
+// DeleteConfigsByKeys deletes configs of the writable layer
+func (c *Client) DeleteConfigsByKeys(keys []string, labels map[string]string) (map[string]interface{}, error) {
+	if c.writable == nil {
+		return nil, ErrReadOnly
+	}
+	return c.writable.DeleteConfigsByKeys(keys, labels)
+}
+
+// Watch watches all layers, a layer is pulled again after it changed, a pull which finishes after a later pull is dropped,
+// then f is called with the merged configs if the merged configs changed,
+// so a change hidden by a higher precedence layer does not call f.
+// layers which can not be watched are treated as static
+func (c *Client) Watch(f func(map[string]interface{}), errHandler func(err error), labels map[string]string) error {
+	snapshots := make([]map[string]interface{}, len(c.layers))
+	for i, l := range c.layers {
+		m, err := l.PullConfigs(labels)
+		if err != nil {
+			return err
+		}
+		snapshots[i] = m
+	}
+	var mu sync.Mutex
+	last := merge(snapshots)
+	//pulls of a layer are numbered when they start, a pull which finishes after a later one is stale
+	started := make([]uint64, len(c.layers))
+	applied := make([]uint64, len(c.layers))
+	for i, l := range c.layers {
+		w, ok := config.AsWatcher(l)
+		if !ok {
+			continue
+		}
+		i, l := i, l
+		err := w.Watch(func(map[string]interface{}) {
+			//callback of some plugins, like config_center, only carries changed keys, so pull the layer again
+			mu.Lock()
+			started[i]++
+			seq := started[i]
+			mu.Unlock()
+			m, err := l.PullConfigs(labels)
+			if err != nil {
+				errHandler(err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if seq < applied[i] {
+				return
+			}
+			applied[i] = seq
+			snapshots[i] = m
+			merged := merge(snapshots)
+			if reflect.DeepEqual(merged, last) {
+				return
+			}
+			last = merged
+			f(merged)
+		}, errHandler, labels)
+		if err != nil {
+			openlogging.Warn("layer can not be watched, it is static", openlogging.WithTags(
+				openlogging.Tags{
+					"layer": i,
+					"err":   err.Error(),
+				}))
+		}
+	}
+	return nil
+}
+
+// Close closes layers which are io.Closer, it returns the first error
+func (c *Client) Close() error {
+	var result error
+	for _, l := range c.layers {
+		if closer, ok := l.(io.Closer); ok {
+			if err := closer.Close(); err != nil && result == nil {
+				result = err
+			}
+		}
+	}
+	return result
+}
+
+// Capabilities reports write if it has a writable layer, and watch if any layer can be watched
+func (c *Client) Capabilities() []config.Capability {
+	result := []config.Capability{config.CapabilityRead}
+	if _, ok := config.AsWriter(c.writable); ok {
+		result = append(result, config.CapabilityWrite)
+	}
+	for _, l := range c.layers {
+		if _, ok := config.AsWatcher(l); ok {
+			return append(result, config.CapabilityWatch)
+		}
+	}
+	return result
+}
+
+// Options return settings of the writable layer, or the highest precedence layer if it is read only
+func (c *Client) Options() config.Options {
+	if c.writable != nil {
+		return c.writable.Options()
+	}
+	return c.layers[len(c.layers)-1].Options()
+}
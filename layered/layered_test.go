@@ -0,0 +1,191 @@
+package layered_test
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/go-chassis/go-chassis-config/env"
+	"github.com/go-chassis/go-chassis-config/layered"
+	"github.com/stretchr/testify/assert"
+)
+
+// memClient is a in memory config client which notifies watchers after every write,
+// if delta is true, watchers only receive changed keys like config_center
+type memClient struct {
+	sync.Mutex
+	kv       map[string]interface{}
+	delta    bool
+	watchers []func(map[string]interface{})
+}
+
+func (m *memClient) snapshot() map[string]interface{} {
+	s := make(map[string]interface{}, len(m.kv))
+	for k, v := range m.kv {
+		s[k] = v
+	}
+	return s
+}
+
+// notify calls watchers without holding the lock, so they can pull configs
+func (m *memClient) notify(changed map[string]interface{}) {
+	m.Lock()
+	watchers := m.watchers
+	kv := m.snapshot()
+	m.Unlock()
+	if m.delta {
+		kv = changed
+	}
+	for _, w := range watchers {
+		w(kv)
+	}
+}
+
+func (m *memClient) PullConfigs(labels ...map[string]string) (map[string]interface{}, error) {
+	m.Lock()
+	defer m.Unlock()
+	return m.snapshot(), nil
+}
+
+func (m *memClient) PullConfig(key, contentType string, labels map[string]string) (interface{}, error) {
+	m.Lock()
+	defer m.Unlock()
+	v, ok := m.kv[key]
+	if !ok {
+		return nil, config.ErrKeyNotExist
+	}
+	return v, nil
+}
+
+func (m *memClient) PushConfigs(items map[string]interface{}, labels map[string]string) (map[string]interface{}, error) {
+	m.Lock()
+	for k, v := range items {
+		m.kv[k] = v
+	}
+	m.Unlock()
+	m.notify(items)
+	return map[string]interface{}{}, nil
+}
+
+func (m *memClient) DeleteConfigsByKeys(keys []string, labels map[string]string) (map[string]interface{}, error) {
+	m.Lock()
+	deleted := make(map[string]interface{}, len(keys))
+	for _, k := range keys {
+		deleted[k] = m.kv[k]
+		delete(m.kv, k)
+	}
+	m.Unlock()
+	m.notify(deleted)
+	return map[string]interface{}{}, nil
+}
+
+func (m *memClient) Watch(f func(map[string]interface{}), errHandler func(err error), labels map[string]string) error {
+	m.Lock()
+	defer m.Unlock()
+	m.watchers = append(m.watchers, f)
+	return nil
+}
+
+func (m *memClient) Options() config.Options {
+	return config.Options{}
+}
+
+func TestClient(t *testing.T) {
+	os.Setenv("LAYERED_TEST_DB_HOST", "127.0.0.1")
+	defer os.Unsetenv("LAYERED_TEST_DB_HOST")
+	envClient, err := env.NewClient(config.Options{Params: map[string]string{env.ParamPrefix: "LAYERED_TEST_"}})
+	assert.NoError(t, err)
+	defaults := &memClient{kv: map[string]interface{}{"db.host": "localhost", "db.port": "3306"}}
+	remote := &memClient{kv: map[string]interface{}{"db.port": "3307", "db.name": "mall"}, delta: true}
+
+	_, err = layered.New([]config.Client{defaults, envClient}, remote)
+	assert.Equal(t, layered.ErrWritableNotLayer, err)
+	readOnly, err := layered.New([]config.Client{defaults}, nil)
+	assert.NoError(t, err)
+	_, err = readOnly.PushConfigs(map[string]interface{}{"a": "b"}, nil)
+	assert.Equal(t, layered.ErrReadOnly, err)
+
+	c, err := layered.New([]config.Client{defaults, remote, envClient}, remote)
+	assert.NoError(t, err)
+	m, err := c.PullConfigs()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"db.host": "127.0.0.1", "db.port": "3307", "db.name": "mall"}, m)
+	v, err := c.PullConfig("db.port", "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "3307", v)
+	_, err = c.PullConfig("db.user", "", nil)
+	assert.Equal(t, config.ErrKeyNotExist, err)
+
+	events := make([]map[string]interface{}, 0)
+	err = c.Watch(func(m map[string]interface{}) {
+		events = append(events, m)
+	}, func(err error) {
+		t.Error(err)
+	}, nil)
+	assert.NoError(t, err)
+
+	// overridden by environment variable, merged view does not change
+	_, err = c.PushConfigs(map[string]interface{}{"db.host": "10.0.0.1"}, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+	assert.Equal(t, "10.0.0.1", remote.kv["db.host"])
+
+	_, err = c.DeleteConfigsByKeys([]string{"db.port"}, nil)
+	assert.NoError(t, err)
+	//the deleted key of remote falls back to defaults, other keys of remote are kept
+	assert.Len(t, events, 1)
+	assert.Equal(t, map[string]interface{}{"db.host": "127.0.0.1", "db.port": "3306", "db.name": "mall"}, events[0])
+}
+
+// stallClient blocks a pull after it read configs if stall is set
+type stallClient struct {
+	*memClient
+	stall   chan struct{}
+	stalled chan struct{}
+}
+
+func (s *stallClient) PullConfigs(labels ...map[string]string) (map[string]interface{}, error) {
+	m, err := s.memClient.PullConfigs(labels...)
+	s.Lock()
+	stall := s.stall
+	s.stall = nil
+	s.Unlock()
+	if stall != nil {
+		close(s.stalled)
+		<-stall
+	}
+	return m, err
+}
+
+func TestClient_WatchStalePull(t *testing.T) {
+	remote := &stallClient{memClient: &memClient{kv: map[string]interface{}{"a": "1"}, delta: true}, stalled: make(chan struct{})}
+	c, err := layered.New([]config.Client{remote}, nil)
+	assert.NoError(t, err)
+	events := make(chan map[string]interface{}, 10)
+	err = c.Watch(func(m map[string]interface{}) {
+		events <- m
+	}, func(err error) {
+		t.Error(err)
+	}, nil)
+	assert.NoError(t, err)
+
+	stall := make(chan struct{})
+	remote.Lock()
+	remote.stall = stall
+	remote.Unlock()
+	done := make(chan struct{})
+	go func() {
+		remote.PushConfigs(map[string]interface{}{"a": "2"}, nil)
+		close(done)
+	}()
+	<-remote.stalled
+	_, err = remote.PushConfigs(map[string]interface{}{"a": "3"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": "3"}, <-events)
+
+	//the pull started first finishes last, its older snapshot is dropped
+	close(stall)
+	<-done
+	assert.Len(t, events, 0)
+}
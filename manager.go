@@ -0,0 +1,116 @@
+package config
+
+import (
+	"io"
+	"sync"
+
+	"github.com/go-mesh/openlogging"
+)
+
+// DefaultClientName is the name of default client in GetClient
+const DefaultClientName = "default"
+
+var (
+	clientsMu    sync.RWMutex
+	clients      = make(map[string]Client)
+	replaceHooks = make([]*replaceHook, 0)
+)
+
+type replaceHook struct {
+	f func(name string, old, new Client)
+}
+
+// SetDefaultClient replaces the default client, the former one is closed if it is an io.Closer
+func SetDefaultClient(c Client) {
+	SetClient(DefaultClientName, c)
+}
+
+// GetDefaultClient returns the default client, it is safe to call it concurrently,
+// use it instead of reading DefaultClient
+func GetDefaultClient() Client {
+	return GetClient(DefaultClientName)
+}
+
+// GetClient returns a named client, it is nil if the name is not set
+func GetClient(name string) Client {
+	clientsMu.RLock()
+	defer clientsMu.RUnlock()
+	return clients[name]
+}
+
+// SetClient replaces a named client, for example after settings of config server changed.
+// hooks are called with the former and the new client, then the former one is closed if it is an io.Closer.
+// a nil client removes the name
+func SetClient(name string, c Client) {
+	old := setClient(name, c)
+	if old == nil || old == c {
+		return
+	}
+	if closer, ok := old.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			openlogging.GetLogger().Errorf("close client [%s] failed: %s", name, err)
+		}
+	}
+}
+
+func setClient(name string, c Client) Client {
+	clientsMu.Lock()
+	old := clients[name]
+	if c == nil {
+		delete(clients, name)
+	} else {
+		clients[name] = c
+	}
+	if name == DefaultClientName {
+		DefaultClient = c
+	}
+	hooks := replaceHooks
+	clientsMu.Unlock()
+	for _, h := range hooks {
+		h.f(name, old, c)
+	}
+	return old
+}
+
+// OnReplace registers a hook which is called after a named client is set, old or new can be nil.
+// it returns a function which removes the hook
+func OnReplace(hook func(name string, old, new Client)) (remove func()) {
+	h := &replaceHook{f: hook}
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	replaceHooks = append(replaceHooks, h)
+	return func() {
+		clientsMu.Lock()
+		defer clientsMu.Unlock()
+		for i, r := range replaceHooks {
+			if r == h {
+				//copy on write, setClient calls hooks without lock
+				replaceHooks = append(replaceHooks[:i:i], replaceHooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// CloseClients closes and removes all named clients, it returns the first close error
+func CloseClients() error {
+	clientsMu.Lock()
+	all := clients
+	clients = make(map[string]Client)
+	DefaultClient = nil
+	clientsMu.Unlock()
+	var result error
+	for name, c := range all {
+		closer, ok := c.(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			openlogging.GetLogger().Errorf("close client [%s] failed: %s", name, err)
+			if result == nil {
+				result = err
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package config_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/stretchr/testify/assert"
+)
+
+type closableClient struct {
+	config.Client
+	closed bool
+}
+
+func (c *closableClient) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestNewClientSetsDefault(t *testing.T) {
+	c, err := config.NewClient("config_center", config.Options{
+		ServerURI: "http://127.0.0.1:30100",
+		Labels:    map[string]string{config.LabelApp: "default"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, c, config.DefaultClient)
+	assert.Equal(t, c, config.GetDefaultClient())
+}
+
+func TestSetClient(t *testing.T) {
+	replaced := make([]string, 0)
+	remove := config.OnReplace(func(name string, old, new config.Client) {
+		replaced = append(replaced, name)
+	})
+	defer remove()
+	first := &closableClient{}
+	second := &closableClient{}
+	config.SetClient("kie", first)
+	assert.Equal(t, first, config.GetClient("kie"))
+	config.SetClient("kie", second)
+	assert.True(t, first.closed)
+	assert.False(t, second.closed)
+	assert.Equal(t, []string{"kie", "kie"}, replaced)
+
+	config.SetDefaultClient(first)
+	assert.Equal(t, first, config.DefaultClient)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			config.GetDefaultClient()
+			config.GetClient("kie")
+		}()
+	}
+	wg.Wait()
+
+	assert.NoError(t, config.CloseClients())
+	assert.True(t, second.closed)
+	assert.Nil(t, config.GetClient("kie"))
+	assert.Nil(t, config.GetDefaultClient())
+
+	remove()
+	config.SetClient("kie", first)
+	defer config.SetClient("kie", nil)
+	assert.Equal(t, []string{"kie", "kie", "default"}, replaced)
+}
@@ -1,6 +1,9 @@
 package config
 
-import "crypto/tls"
+import (
+	"crypto/tls"
+	"time"
+)
 
 type Options struct {
 	ServerURI     string
@@ -11,6 +14,10 @@ type Options struct {
 	APIVersion    string
 	AutoDiscovery bool
 	RefreshPort   string
+	// WatchDuration is the interval of plugins which watch changes by polling
+	WatchDuration time.Duration
 
 	Labels map[string]string
+	// Params holds plugin specific settings, each plugin documents the keys it reads
+	Params map[string]string
 }
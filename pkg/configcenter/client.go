@@ -49,6 +49,8 @@ const (
 	dimensionsInfo           = "dimensionsInfo"
 	dynamicConfigAPI         = `/configuration/refresh/items`
 	getConfigAPI             = `/configuration/items`
+	historyAPI               = `/configuration/history`
+	revisionAPI              = `/configuration/revisions`
 	defaultContentType       = "application/json"
 	envProjectID             = "CSE_PROJECT_ID"
 	packageInitError         = "package not initialize successfully"
@@ -67,11 +69,27 @@ var (
 	ConfigPath = ""
 	//ConfigRefreshPath is a variable of type string
 	ConfigRefreshPath = ""
-	autoDiscoverable  = false
-	apiVersionConfig  = ""
-	environmentConfig = ""
+	//HistoryPath is the path of config history api
+	HistoryPath = ""
+	//RevisionPath is the path of config revisions api
+	RevisionPath     = ""
+	autoDiscoverable = false
+	apiVersionConfig = ""
 )
 
+//ErrClosed means client is closed
+var ErrClosed = errors.New("client is closed")
+
+//StatusError is returned when config center responds a status which is not 2xx
+type StatusError struct {
+	Code int
+	Body []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("statusCode: %d, resp body: %s", e.Code, e.Body)
+}
+
 //Client is a struct
 type Client struct {
 	opts Options
@@ -79,9 +97,20 @@ type Client struct {
 	c            *httpclient.Requests
 	wsDialer     *websocket.Dialer
 	wsConnection *websocket.Conn
+	closed       bool
+
+	//subMu guards subscriptions, all of them share one websocket connection
+	subMu    sync.Mutex
+	subs     []*Subscription
+	watching bool
+	//done stops polling watch after client is closed
+	done chan struct{}
 }
 
 func New(opts Options) (*Client, error) {
+	if err := ValidateEnvironment(opts.Env); err != nil {
+		return nil, err
+	}
 	var apiVersion string
 	apiVersionConfig = opts.APIVersion
 	switch apiVersionConfig {
@@ -113,6 +142,7 @@ func New(opts Options) (*Client, error) {
 			TLSClientConfig:  opts.TLSConfig,
 			HandshakeTimeout: defaultTimeout,
 		},
+		done: make(chan struct{}),
 	}
 	c.Shuffle()
 	return c, nil
@@ -131,14 +161,20 @@ func updateAPIPath(apiVersion string) {
 		ConfigMembersPath = "/v3/" + projectID + members
 		ConfigPath = "/v3/" + projectID + getConfigAPI
 		ConfigRefreshPath = "/v3/" + projectID + dynamicConfigAPI
+		HistoryPath = "/v3/" + projectID + historyAPI
+		RevisionPath = "/v3/" + projectID + revisionAPI
 	case "v2":
 		ConfigMembersPath = "/members"
 		ConfigPath = "/configuration/v2/items"
 		ConfigRefreshPath = "/configuration/v2/refresh/items"
+		HistoryPath = "/configuration/v2/history"
+		RevisionPath = "/configuration/v2/revisions"
 	default:
 		ConfigMembersPath = "/v3/" + projectID + members
 		ConfigPath = "/v3/" + projectID + getConfigAPI
 		ConfigRefreshPath = "/v3/" + projectID + dynamicConfigAPI
+		HistoryPath = "/v3/" + projectID + historyAPI
+		RevisionPath = "/v3/" + projectID + revisionAPI
 	}
 }
 
@@ -163,7 +199,7 @@ func (c *Client) call(method string, api string, headers http.Header, body []byt
 		return err
 	}
 	if !isStatusSuccess(resp.StatusCode) {
-		err = fmt.Errorf("statusCode: %d, resp body: %s", resp.StatusCode, body)
+		err = &StatusError{Code: resp.StatusCode, Body: body}
 		openlogging.GetLogger().Error(errMsgPrefix + err.Error())
 		return err
 	}
@@ -189,6 +225,9 @@ func (c *Client) HTTPDo(method string, rawURL string, headers http.Header, body
 	for k, v := range GetDefaultHeaders(c.opts.TenantName) {
 		headers[k] = v
 	}
+	for k, v := range c.environmentHeader() {
+		headers[k] = v
+	}
 	return c.c.Do(context.Background(), method, rawURL, headers, body)
 }
 
@@ -213,7 +252,7 @@ func (c *Client) Flatten(dimensionInfo string) (map[string]interface{}, error) {
 func (c *Client) PullGroupByDimension(dimensionInfo string) (map[string]map[string]interface{}, error) {
 	configAPIRes := make(map[string]map[string]interface{})
 	parsedDimensionInfo := strings.Replace(dimensionInfo, "#", "%23", -1)
-	restApi := ConfigPath + "?" + dimensionsInfo + "=" + parsedDimensionInfo
+	restApi := c.withEnvironment(ConfigPath + "?" + dimensionsInfo + "=" + parsedDimensionInfo)
 	err := c.call(http.MethodGet, restApi, nil, nil, &configAPIRes)
 	if err != nil {
 		openlogging.GetLogger().Error("Flatten config failed:" + err.Error())
@@ -230,67 +269,133 @@ func (c *Client) Do(method string, data interface{}) (map[string]interface{}, er
 		openlogging.GetLogger().Errorf("serializer data failed , err :", err.Error())
 		return nil, err
 	}
-	err = c.call(method, ConfigPath, nil, body, &configAPIS)
+	err = c.call(method, c.withEnvironment(ConfigPath), nil, body, &configAPIS)
 	if err != nil {
 		return nil, err
 	}
 	return configAPIS, nil
 }
+
+//AddConfig writes items, if data.If is set, a rejected precondition returns *ConflictError,
+//and ErrConditionNotSupported is returned without writing if config center does not provide revisions api
 func (c *Client) AddConfig(data *CreateConfigApi) (map[string]interface{}, error) {
-	return c.Do("POST", data)
+	return c.write("POST", data.DimensionInfo, data, data.If)
 }
 
+//DeleteConfig deletes keys, if data.If is set, a rejected precondition returns *ConflictError
 func (c *Client) DeleteConfig(data *DeleteConfigApi) (map[string]interface{}, error) {
-	return c.Do("DELETE", data)
+	return c.write("DELETE", data.DimensionInfo, data, data.If)
 }
 
+//Watch calls f with configs of every event
 func (c *Client) Watch(f func(map[string]interface{}), errHandler func(err error)) error {
+	return c.WatchEvents(func(e *Event, kv map[string]interface{}) {
+		f(kv)
+	}, errHandler)
+}
+
+//WatchEvents calls f with every event and configs decoded from its value
+func (c *Client) WatchEvents(f func(e *Event, kv map[string]interface{}), errHandler func(err error)) error {
+	_, err := c.Subscribe(nil, f, errHandler)
+	return err
+}
+
+//dial connects the websocket of watch and dispatches its events to subscriptions,
+//it must be called with subMu locked
+func (c *Client) dial() error {
+	if c.opts.WatchMode == WatchModePolling {
+		return c.poll()
+	}
+	err := c.dialWebSocket()
+	if err == nil || err == ErrClosed || c.opts.WatchMode == WatchModeWebSocket {
+		return err
+	}
+	openlogging.GetLogger().Warnf("%s, watch by polling instead", err)
+	return c.poll()
+}
+
+func (c *Client) dialWebSocket() error {
 	parsedDimensionInfo := strings.Replace(c.opts.DefaultDimension, "#", "%23", -1)
-	refreshConfigPath := ConfigRefreshPath + `?` + dimensionsInfo + `=` + parsedDimensionInfo
-	if c.wsDialer != nil {
-		/*-----------------
-		1. Decide on the URL
-		2. Create WebSocket Connection
-		3. Call KeepAlive in separate thread
-		3. Generate events on Receive Data
-		*/
-		baseURL, err := c.getWebSocketURL()
-		if err != nil {
-			error := errors.New("error in getting default server info")
-			return error
-		}
-		url := baseURL.String() + refreshConfigPath
-		c.wsConnection, _, err = c.wsDialer.Dial(url, nil)
-		if err != nil {
-			return fmt.Errorf("watching config-center dial catch an exception error:%s", err.Error())
-		}
-		keepAlive(c.wsConnection, 15*time.Second)
-		go func() error {
-			for {
-				messageType, message, err := c.wsConnection.ReadMessage()
-				if err != nil {
-					break
-				}
-				if messageType == websocket.TextMessage {
-					m, err := GetConfigs(message)
-					if err != nil {
-						errHandler(err)
-						continue
-					}
-					f(m)
-				}
-			}
-			err = c.wsConnection.Close()
+	refreshConfigPath := c.withEnvironment(ConfigRefreshPath + `?` + dimensionsInfo + `=` + parsedDimensionInfo)
+	/*-----------------
+	1. Decide on the URL
+	2. Create WebSocket Connection
+	3. Call KeepAlive in separate thread
+	3. Generate events on Receive Data
+	*/
+	baseURL, err := c.getWebSocketURL()
+	if err != nil {
+		error := errors.New("error in getting default server info")
+		return error
+	}
+	url := baseURL.String() + refreshConfigPath
+	conn, _, err := c.wsDialer.Dial(url, c.environmentHeader())
+	if err != nil {
+		return fmt.Errorf("watching config-center dial catch an exception error:%s", err.Error())
+	}
+	c.Lock()
+	if c.closed {
+		c.Unlock()
+		conn.Close()
+		return ErrClosed
+	}
+	c.wsConnection = conn
+	c.Unlock()
+	c.watching = true
+	keepAlive(conn, 15*time.Second)
+	go func() error {
+		for {
+			messageType, message, err := conn.ReadMessage()
 			if err != nil {
-				openlogging.Error(err.Error())
-				return fmt.Errorf("CC watch Conn close failed error:%s", err.Error())
+				break
+			}
+			if messageType == websocket.TextMessage {
+				e, m, err := DecodeEvent(message)
+				c.dispatch(e, m, err)
 			}
+		}
+		//subscriptions are kept, next Subscribe dials again
+		c.subMu.Lock()
+		c.watching = false
+		c.subMu.Unlock()
+		c.Lock()
+		closed := c.closed
+		if c.wsConnection == conn {
+			c.wsConnection = nil
+		}
+		c.Unlock()
+		if closed {
 			return nil
-		}()
-	}
+		}
+		err := conn.Close()
+		if err != nil {
+			openlogging.Error(err.Error())
+			return fmt.Errorf("CC watch Conn close failed error:%s", err.Error())
+		}
+		return nil
+	}()
 	return nil
 }
 
+//Close closes the websocket connection of watch, the client can not watch after it is closed
+func (c *Client) Close() error {
+	c.Lock()
+	conn := c.wsConnection
+	c.wsConnection = nil
+	if !c.closed {
+		close(c.done)
+	}
+	c.closed = true
+	c.Unlock()
+	c.subMu.Lock()
+	c.subs = nil
+	c.subMu.Unlock()
+	if conn == nil {
+		return nil
+	}
+	return conn.Close()
+}
+
 func keepAlive(c *websocket.Conn, timeout time.Duration) {
 	lastResponse := time.Now()
 	c.SetPongHandler(func(msg string) error {
@@ -373,19 +478,25 @@ func (c *Client) GetConfigServer() ([]string, error) {
 
 //GetConfigs get KV from a event
 func GetConfigs(actionData []byte) (map[string]interface{}, error) {
+	_, sourceConfig, err := DecodeEvent(actionData)
+	return sourceConfig, err
+}
+
+//DecodeEvent decodes a event and KV of its value
+func DecodeEvent(actionData []byte) (*Event, map[string]interface{}, error) {
 	configCenterEvent := new(Event)
 	err := serializers.Decode(serializers.JsonEncoder, actionData, &configCenterEvent)
 	if err != nil {
 		openlogging.GetLogger().Errorf(fmt.Sprintf("error in unmarshalling data on event receive with error %s", err.Error()))
-		return nil, err
+		return nil, nil, err
 	}
 	sourceConfig := make(map[string]interface{})
 	err = serializers.Decode(serializers.JsonEncoder, []byte(configCenterEvent.Value), &sourceConfig)
 	if err != nil {
 		openlogging.GetLogger().Errorf(fmt.Sprintf("error in unmarshalling config values %s", err.Error()))
-		return nil, err
+		return nil, nil, err
 	}
-	return sourceConfig, nil
+	return configCenterEvent, sourceConfig, nil
 }
 
 func (c *Client) getWebSocketURL() (*url.URL, error) {
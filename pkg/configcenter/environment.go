@@ -0,0 +1,81 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package configcenter
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// environments of services, configs of different environments are isolated in config center
+const (
+	EnvironmentDevelopment = "development"
+	EnvironmentTesting     = "testing"
+	EnvironmentAcceptance  = "acceptance"
+	EnvironmentProduction  = "production"
+
+	queryEnvironment = "environment"
+)
+
+// AllowedEnvironments is the environments a client can use, empty environment is always allowed
+var AllowedEnvironments = []string{EnvironmentDevelopment, EnvironmentTesting, EnvironmentAcceptance, EnvironmentProduction}
+
+// EnvironmentError means environment is not one of AllowedEnvironments
+type EnvironmentError struct {
+	Environment string
+}
+
+func (e *EnvironmentError) Error() string {
+	return fmt.Sprintf("invalid environment %q, allowed values are %s", e.Environment, strings.Join(AllowedEnvironments, ", "))
+}
+
+// ValidateEnvironment checks environment against AllowedEnvironments
+func ValidateEnvironment(env string) error {
+	if env == "" {
+		return nil
+	}
+	for _, e := range AllowedEnvironments {
+		if env == e {
+			return nil
+		}
+	}
+	return &EnvironmentError{Environment: env}
+}
+
+// withEnvironment adds environment query to api, so that requests are isolated even if a proxy drops headers
+func (c *Client) withEnvironment(api string) string {
+	if c.opts.Env == "" {
+		return api
+	}
+	sep := "?"
+	if strings.Contains(api, "?") {
+		sep = "&"
+	}
+	return api + sep + queryEnvironment + "=" + url.QueryEscape(c.opts.Env)
+}
+
+// environmentHeader returns headers of the environment of client
+func (c *Client) environmentHeader() http.Header {
+	headers := make(http.Header)
+	if c.opts.Env != "" {
+		headers.Set(HeaderEnvironment, c.opts.Env)
+	}
+	return headers
+}
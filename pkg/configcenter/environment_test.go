@@ -0,0 +1,47 @@
+package configcenter_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chassis/go-chassis-config/pkg/configcenter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateEnvironment(t *testing.T) {
+	assert.NoError(t, configcenter.ValidateEnvironment(""))
+	assert.NoError(t, configcenter.ValidateEnvironment(configcenter.EnvironmentProduction))
+	err := configcenter.ValidateEnvironment("staging")
+	assert.IsType(t, &configcenter.EnvironmentError{}, err)
+
+	_, err = configcenter.New(configcenter.Options{ConfigServerAddresses: []string{"http://127.0.0.1:30103"}, Env: "staging"})
+	assert.Error(t, err)
+}
+
+func TestClient_Environment(t *testing.T) {
+	for _, env := range []string{"", configcenter.EnvironmentTesting} {
+		t.Run(env, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, env, r.Header.Get(configcenter.HeaderEnvironment))
+				assert.Equal(t, env, r.URL.Query().Get("environment"))
+				w.Header().Set("Content-Type", "application/json")
+				if r.Method == http.MethodGet {
+					assert.Equal(t, "cart@default#1.0.0", r.URL.Query().Get("dimensionsInfo"))
+					io.WriteString(w, `{"cart@default#1.0.0":{"a":"1"}}`)
+					return
+				}
+				io.WriteString(w, `{"Result":"Success"}`)
+			}))
+			defer s.Close()
+			c, err := configcenter.New(configcenter.Options{ConfigServerAddresses: []string{s.URL}, Env: env})
+			assert.NoError(t, err)
+			kv, err := c.Flatten("cart@default#1.0.0")
+			assert.NoError(t, err)
+			assert.Equal(t, map[string]interface{}{"a": "1"}, kv)
+			_, err = c.AddConfig(&configcenter.CreateConfigApi{DimensionInfo: "cart@default#1.0.0", Items: map[string]interface{}{"a": "2"}})
+			assert.NoError(t, err)
+		})
+	}
+}
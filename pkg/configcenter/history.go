@@ -0,0 +1,90 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package configcenter
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// errors of history
+var (
+	ErrHistoryNotSupported = errors.New("config center does not provide history api")
+	ErrRevisionNotExist    = errors.New("revision does not exist")
+)
+
+// HistoryItem is a historical value of a config, Value is nil if the action is DELETE
+type HistoryItem struct {
+	Key      string      `json:"key"`
+	Value    interface{} `json:"value"`
+	Action   string      `json:"action"`
+	Revision string      `json:"revision"`
+	Author   string      `json:"author"`
+	//Timestamp is the unix time in seconds
+	Timestamp int64 `json:"timestamp"`
+}
+
+// HistoryResponse is the response of history api
+type HistoryResponse struct {
+	Items []HistoryItem `json:"items"`
+}
+
+// history queries history api, revision is optional
+func (c *Client) history(dimensionInfo, key, revision string) ([]HistoryItem, error) {
+	q := url.Values{}
+	q.Set(dimensionsInfo, dimensionInfo)
+	q.Set("key", key)
+	if revision != "" {
+		q.Set("revision", revision)
+	}
+	resp := &HistoryResponse{}
+	err := c.call(http.MethodGet, c.withEnvironment(HistoryPath+"?"+q.Encode()), nil, nil, resp)
+	if se, ok := err.(*StatusError); ok && (se.Code == http.StatusNotFound || se.Code == http.StatusNotImplemented) {
+		return nil, ErrHistoryNotSupported
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range resp.Items {
+		if strings.EqualFold(resp.Items[i].Action, "delete") {
+			resp.Items[i].Value = nil
+		}
+	}
+	return resp.Items, nil
+}
+
+// History lists history of a key in a dimension, latest first
+func (c *Client) History(dimensionInfo, key string) ([]HistoryItem, error) {
+	return c.history(dimensionInfo, key, "")
+}
+
+// Revision returns a revision of a key in a dimension
+func (c *Client) Revision(dimensionInfo, key, revision string) (*HistoryItem, error) {
+	items, err := c.history(dimensionInfo, key, revision)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if items[i].Revision == revision {
+			return &items[i], nil
+		}
+	}
+	return nil, ErrRevisionNotExist
+}
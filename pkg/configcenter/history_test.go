@@ -0,0 +1,50 @@
+package configcenter_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chassis/go-chassis-config/pkg/configcenter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_History(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/default/configuration/history", r.URL.Path)
+		assert.Equal(t, "cart@default#1.0.0", r.URL.Query().Get("dimensionsInfo"))
+		assert.Equal(t, "timeout", r.URL.Query().Get("key"))
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("revision") == "3" {
+			io.WriteString(w, `{"items":[]}`)
+			return
+		}
+		io.WriteString(w, `{"items":[
+			{"key":"timeout","action":"DELETE","value":"2s","revision":"2","author":"tom","timestamp":1600000100},
+			{"key":"timeout","action":"CREATE","value":"1s","revision":"1","author":"tom","timestamp":1600000000}]}`)
+	}))
+	defer s.Close()
+	c, err := configcenter.New(configcenter.Options{ConfigServerAddresses: []string{s.URL}})
+	assert.NoError(t, err)
+
+	items, err := c.History("cart@default#1.0.0", "timeout")
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+	assert.Nil(t, items[0].Value)
+	assert.Equal(t, "1s", items[1].Value)
+	assert.Equal(t, int64(1600000000), items[1].Timestamp)
+
+	item, err := c.Revision("cart@default#1.0.0", "timeout", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "1s", item.Value)
+	_, err = c.Revision("cart@default#1.0.0", "timeout", "3")
+	assert.Equal(t, configcenter.ErrRevisionNotExist, err)
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	c, err = configcenter.New(configcenter.Options{ConfigServerAddresses: []string{notFound.URL}})
+	assert.NoError(t, err)
+	_, err = c.History("cart@default#1.0.0", "timeout")
+	assert.Equal(t, configcenter.ErrHistoryNotSupported, err)
+}
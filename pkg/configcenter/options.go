@@ -20,6 +20,7 @@ package configcenter
 import (
 	"crypto/tls"
 	"net/http"
+	"time"
 )
 
 type Options struct {
@@ -27,7 +28,9 @@ type Options struct {
 	Service          string
 	App              string
 	Version          string
-	Env              string
+	//Env is the environment of service, it must be one of AllowedEnvironments,
+	//it is sent by header and query of every request to isolate configs of environments
+	Env string
 
 	ConfigServerAddresses []string
 	RefreshPort           string
@@ -35,6 +38,10 @@ type Options struct {
 	TLSConfig             *tls.Config
 	TenantName            string
 	EnableSSL             bool
+	//WatchMode decides how to watch, default is WatchModeAuto
+	WatchMode string
+	//PollInterval is the interval of polling watch, default is 30s
+	PollInterval time.Duration
 }
 
 //GetDefaultHeaders gets default headers
@@ -44,9 +51,5 @@ func GetDefaultHeaders(tenantName string) http.Header {
 		HeaderUserAgent:   []string{"cse-configcenter-client/1.0.0"},
 		HeaderTenantName:  []string{tenantName},
 	}
-	if environmentConfig != "" {
-		headers.Set(HeaderEnvironment, environmentConfig)
-	}
-
 	return headers
 }
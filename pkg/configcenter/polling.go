@@ -0,0 +1,114 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package configcenter
+
+import (
+	"crypto/sha256"
+	"reflect"
+	"time"
+
+	"github.com/go-chassis/go-chassis-config/pkg/util"
+	"github.com/go-chassis/go-chassis-config/serializers"
+)
+
+// watch modes
+const (
+	//WatchModeAuto watches by websocket, and by polling if websocket can not be connected
+	WatchModeAuto = ""
+	//WatchModeWebSocket watches by websocket only
+	WatchModeWebSocket = "websocket"
+	//WatchModePolling watches by polling, for networks which block websocket or refresh port
+	WatchModePolling = "polling"
+
+	defaultPollInterval = 30 * time.Second
+)
+
+// hash returns the content hash of configs, json encoding sorts keys so equal configs have equal hashes
+func hash(kv map[string]interface{}) ([32]byte, error) {
+	b, err := serializers.Encode(serializers.JsonEncoder, kv)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return sha256.Sum256(b), nil
+}
+
+// poll pulls configs of default dimension periodically, when their hash changed, changed and new keys are dispatched
+// to subscriptions by an UPDATE event and removed keys by a DELETE event, like websocket events.
+// it must be called with subMu locked
+func (c *Client) poll() error {
+	last, err := c.Flatten(c.opts.DefaultDimension)
+	if err != nil {
+		return err
+	}
+	lastHash, err := hash(last)
+	if err != nil {
+		return err
+	}
+	d := c.opts.PollInterval
+	if d <= 0 {
+		d = defaultPollInterval
+	}
+	c.watching = true
+	go func() {
+		for range util.Tick(d, c.done) {
+			kv, err := c.Flatten(c.opts.DefaultDimension)
+			var h [32]byte
+			if err == nil {
+				h, err = hash(kv)
+			}
+			if err == nil && h == lastHash {
+				continue
+			}
+			select {
+			case <-c.done:
+				return
+			default:
+			}
+			if err != nil {
+				c.dispatch(nil, nil, err)
+				continue
+			}
+			updated, deleted := diff(last, kv)
+			last, lastHash = kv, h
+			if len(updated) != 0 {
+				c.dispatch(&Event{Action: "UPDATE"}, updated, nil)
+			}
+			if len(deleted) != 0 {
+				c.dispatch(&Event{Action: "DELETE"}, deleted, nil)
+			}
+		}
+	}()
+	return nil
+}
+
+// diff returns changed and new configs, and removed configs with their old values
+func diff(old, new map[string]interface{}) (updated, deleted map[string]interface{}) {
+	updated = make(map[string]interface{})
+	deleted = make(map[string]interface{})
+	for k, v := range new {
+		if o, ok := old[k]; !ok || !reflect.DeepEqual(o, v) {
+			updated[k] = v
+		}
+	}
+	for k, v := range old {
+		if _, ok := new[k]; !ok {
+			deleted[k] = v
+		}
+	}
+	return updated, deleted
+}
@@ -0,0 +1,115 @@
+package configcenter_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-chassis-config/pkg/configcenter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_WatchPolling(t *testing.T) {
+	var version int32 = 1
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		//websocket is not supported
+		w.Header().Set("Content-Type", "application/json")
+		v := atomic.LoadInt32(&version)
+		if v == 0 {
+			io.WriteString(w, `{"cart@default":{"a":"0"}}`)
+			return
+		}
+		io.WriteString(w, fmt.Sprintf(`{"cart@default":{"a":"%d","b":"1"}}`, v))
+	}))
+	defer s.Close()
+	u, _ := url.Parse(s.URL)
+	for _, mode := range []string{configcenter.WatchModeAuto, configcenter.WatchModePolling} {
+		t.Run("mode "+mode, func(t *testing.T) {
+			atomic.StoreInt32(&version, 1)
+			c, err := configcenter.New(configcenter.Options{
+				ConfigServerAddresses: []string{s.URL},
+				RefreshPort:           u.Port(),
+				DefaultDimension:      "cart@default",
+				WatchMode:             mode,
+				PollInterval:          10 * time.Millisecond,
+			})
+			assert.NoError(t, err)
+			defer c.Close()
+			received := make(chan map[string]interface{}, 4)
+			_, err = c.Subscribe(configcenter.MatchKeys("a"), func(e *configcenter.Event, kv map[string]interface{}) {
+				received <- kv
+			}, func(err error) {})
+			assert.NoError(t, err)
+			atomic.StoreInt32(&version, 2)
+			assert.Equal(t, map[string]interface{}{"a": "2"}, receive(t, received))
+			select {
+			case <-received:
+				t.Fatal("unchanged configs are dispatched")
+			case <-time.After(50 * time.Millisecond):
+			}
+		})
+	}
+	t.Run("watch receives changed and deleted keys", func(t *testing.T) {
+		atomic.StoreInt32(&version, 1)
+		c, err := configcenter.New(configcenter.Options{
+			ConfigServerAddresses: []string{s.URL},
+			DefaultDimension:      "cart@default",
+			WatchMode:             configcenter.WatchModePolling,
+			PollInterval:          10 * time.Millisecond,
+		})
+		assert.NoError(t, err)
+		defer c.Close()
+		type event struct {
+			action string
+			kv     map[string]interface{}
+		}
+		received := make(chan event, 4)
+		err = c.WatchEvents(func(e *configcenter.Event, kv map[string]interface{}) {
+			received <- event{e.Action, kv}
+		}, func(err error) {})
+		assert.NoError(t, err)
+		watched := make(chan map[string]interface{}, 4)
+		assert.NoError(t, c.Watch(func(kv map[string]interface{}) {
+			watched <- kv
+		}, func(err error) {}))
+
+		atomic.StoreInt32(&version, 2)
+		select {
+		case e := <-received:
+			assert.Equal(t, event{"UPDATE", map[string]interface{}{"a": "2"}}, e)
+		case <-time.After(3 * time.Second):
+			t.Fatal("no event received")
+		}
+		assert.Equal(t, map[string]interface{}{"a": "2"}, receive(t, watched))
+
+		//b is removed when version is 0
+		atomic.StoreInt32(&version, 0)
+		select {
+		case e := <-received:
+			assert.Equal(t, event{"UPDATE", map[string]interface{}{"a": "0"}}, e)
+		case <-time.After(3 * time.Second):
+			t.Fatal("no event received")
+		}
+		select {
+		case e := <-received:
+			assert.Equal(t, event{"DELETE", map[string]interface{}{"b": "1"}}, e)
+		case <-time.After(3 * time.Second):
+			t.Fatal("no delete event received")
+		}
+	})
+	t.Run("websocket mode does not fall back", func(t *testing.T) {
+		c, err := configcenter.New(configcenter.Options{
+			ConfigServerAddresses: []string{s.URL},
+			RefreshPort:           u.Port(),
+			WatchMode:             configcenter.WatchModeWebSocket,
+		})
+		assert.NoError(t, err)
+		defer c.Close()
+		assert.Error(t, c.Watch(func(map[string]interface{}) {}, func(err error) {}))
+	})
+}
@@ -0,0 +1,119 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package configcenter
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// KeyStatus is the status of a key in a write request
+type KeyStatus string
+
+// key statuses
+const (
+	KeyStatusSuccess KeyStatus = "success"
+	KeyStatusFailed  KeyStatus = "failed"
+)
+
+const resultSuccess = "success"
+
+// WriteResult is the typed result of AddConfig and DeleteConfig
+type WriteResult struct {
+	//Success is true if config center accepted the request
+	Success bool
+	//Keys holds the status of each key of the request,
+	//config center accepts or rejects a request as a whole, so each key has the status of the request
+	Keys map[string]KeyStatus
+	//Result is the result message of config center, like Success, it is empty if config center does not report it
+	Result string
+	//Revision is the revision after write, it is empty if config center does not report it
+	Revision string
+	//Warnings are reported by config center
+	Warnings []string
+	//Raw is the undecoded response
+	Raw map[string]interface{}
+}
+
+// field returns a field of response, field names are matched case insensitively,
+// v3 api responds {"Result":"Success"} and v2 api may use lower case names
+func field(resp map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := resp[name]; ok {
+		return v, true
+	}
+	for k, v := range resp {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// DecodeWriteResult decodes the response of a write request for keys, it works with v2 and v3 api,
+// the request succeeded if status is not an error status, Result of response overrides it only if it is present
+func DecodeWriteResult(status int, keys []string, resp map[string]interface{}) *WriteResult {
+	r := &WriteResult{Success: isStatusSuccess(status), Keys: make(map[string]KeyStatus, len(keys)), Raw: resp}
+	if v, ok := field(resp, "result"); ok && v != nil {
+		r.Result = fmt.Sprint(v)
+		r.Success = r.Success && strings.EqualFold(r.Result, resultSuccess)
+	}
+	if v, ok := field(resp, "revision"); ok && v != nil {
+		r.Revision = fmt.Sprint(v)
+	}
+	if v, ok := field(resp, "warnings"); ok {
+		switch w := v.(type) {
+		case []interface{}:
+			for _, s := range w {
+				r.Warnings = append(r.Warnings, fmt.Sprint(s))
+			}
+		case string:
+			r.Warnings = append(r.Warnings, w)
+		}
+	}
+	keyStatus := KeyStatusFailed
+	if r.Success {
+		keyStatus = KeyStatusSuccess
+	}
+	for _, k := range keys {
+		r.Keys[k] = keyStatus
+	}
+	return r
+}
+
+// AddConfigResult adds configs and returns the typed result, an error status returns *StatusError
+func (c *Client) AddConfigResult(data *CreateConfigApi) (*WriteResult, error) {
+	resp, err := c.AddConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(data.Items))
+	for k := range data.Items {
+		keys = append(keys, k)
+	}
+	return DecodeWriteResult(http.StatusOK, keys, resp), nil
+}
+
+// DeleteConfigResult deletes configs and returns the typed result, like AddConfigResult
+func (c *Client) DeleteConfigResult(data *DeleteConfigApi) (*WriteResult, error) {
+	resp, err := c.DeleteConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeWriteResult(http.StatusOK, data.Keys, resp), nil
+}
@@ -0,0 +1,59 @@
+package configcenter_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chassis/go-chassis-config/pkg/configcenter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeWriteResult(t *testing.T) {
+	r := configcenter.DecodeWriteResult(http.StatusOK, []string{"a", "b"}, map[string]interface{}{"Result": "Success"})
+	assert.True(t, r.Success)
+	assert.Equal(t, "Success", r.Result)
+	assert.Equal(t, map[string]configcenter.KeyStatus{"a": configcenter.KeyStatusSuccess, "b": configcenter.KeyStatusSuccess}, r.Keys)
+
+	r = configcenter.DecodeWriteResult(http.StatusOK, nil, map[string]interface{}{})
+	assert.True(t, r.Success)
+	assert.Empty(t, r.Result)
+
+	r = configcenter.DecodeWriteResult(http.StatusOK, []string{"a"}, map[string]interface{}{
+		"result": "failed", "revision": float64(12), "warnings": []interface{}{"a is deprecated"}})
+	assert.False(t, r.Success)
+	assert.Equal(t, configcenter.KeyStatusFailed, r.Keys["a"])
+	assert.Equal(t, "12", r.Revision)
+	assert.Equal(t, []string{"a is deprecated"}, r.Warnings)
+
+	r = configcenter.DecodeWriteResult(http.StatusBadRequest, []string{"a"}, map[string]interface{}{"Result": "Success"})
+	assert.False(t, r.Success)
+	assert.Equal(t, configcenter.KeyStatusFailed, r.Keys["a"])
+}
+
+func TestClient_AddConfigResult(t *testing.T) {
+	for _, tc := range []struct {
+		version string
+		path    string
+	}{{"v2", "/configuration/v2/items"}, {"v3", "/v3/default/configuration/items"}} {
+		t.Run(tc.version, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tc.path, r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, `{"Result":"Success"}`)
+			}))
+			defer s.Close()
+			c, err := configcenter.New(configcenter.Options{ConfigServerAddresses: []string{s.URL}, APIVersion: tc.version})
+			assert.NoError(t, err)
+			r, err := c.AddConfigResult(&configcenter.CreateConfigApi{DimensionInfo: "cart@default", Items: map[string]interface{}{"a": "1"}})
+			assert.NoError(t, err)
+			assert.True(t, r.Success)
+			assert.Equal(t, map[string]configcenter.KeyStatus{"a": configcenter.KeyStatusSuccess}, r.Keys)
+			r, err = c.DeleteConfigResult(&configcenter.DeleteConfigApi{DimensionInfo: "cart@default", Keys: []string{"a"}})
+			assert.NoError(t, err)
+			assert.True(t, r.Success)
+			assert.Equal(t, map[string]configcenter.KeyStatus{"a": configcenter.KeyStatusSuccess}, r.Keys)
+		})
+	}
+}
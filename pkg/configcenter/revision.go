@@ -0,0 +1,116 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package configcenter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// errors of conditional writes
+var (
+	ErrConditionNotSupported = errors.New("config center does not support conditional writes")
+	ErrConditionUnconfirmed  = errors.New("config center did not confirm the precondition, the write may have been applied without check")
+)
+
+// Precondition is checked by config center before a write is applied,
+// the write is rejected with 412 Precondition Failed if revisions do not match
+type Precondition struct {
+	//Revision is the expected revision of dimension, empty string means it is not checked
+	Revision string `json:"revision,omitempty"`
+	//Keys holds expected revisions of keys, empty string means the key must not exist
+	Keys map[string]string `json:"keys,omitempty"`
+}
+
+// Revisions is the revision of a dimension and revisions of its keys
+type Revisions struct {
+	Revision string            `json:"revision"`
+	Keys     map[string]string `json:"keys"`
+}
+
+// ConflictError is returned if config center rejects a write because of its precondition,
+// Key is empty if the revision of dimension conflicts
+type ConflictError struct {
+	Key      string `json:"key"`
+	Expected string `json:"expected"`
+	Current  string `json:"current"`
+}
+
+func (e *ConflictError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("revision conflicts, expected [%s], current [%s]", e.Expected, e.Current)
+	}
+	return fmt.Sprintf("revision of key [%s] conflicts, expected [%s], current [%s]", e.Key, e.Expected, e.Current)
+}
+
+// Revisions returns current revisions of a dimension
+func (c *Client) Revisions(dimensionInfo string) (*Revisions, error) {
+	q := url.Values{}
+	q.Set(dimensionsInfo, dimensionInfo)
+	r := &Revisions{}
+	err := c.call(http.MethodGet, c.withEnvironment(RevisionPath+"?"+q.Encode()), nil, nil, r)
+	if se, ok := err.(*StatusError); ok && (se.Code == http.StatusNotFound || se.Code == http.StatusNotImplemented) {
+		return nil, ErrConditionNotSupported
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r.Keys == nil {
+		r.Keys = make(map[string]string)
+	}
+	return r, nil
+}
+
+// write sends a write request, config center checks the precondition if it is not nil,
+// a rejected precondition is returned as *ConflictError.
+// config center which ignores the precondition would apply the write without check,
+// so revisions api is queried first and ErrConditionNotSupported is returned without writing if it is not provided
+func (c *Client) write(method, dimensionInfo string, data interface{}, pre *Precondition) (map[string]interface{}, error) {
+	if pre != nil {
+		if _, err := c.Revisions(dimensionInfo); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := c.Do(method, data)
+	if se, ok := err.(*StatusError); ok && pre != nil {
+		switch se.Code {
+		case http.StatusPreconditionFailed, http.StatusConflict:
+			e := &ConflictError{}
+			if json.Unmarshal(se.Body, e) != nil {
+				e.Expected = pre.Revision
+			}
+			return nil, e
+		case http.StatusNotImplemented:
+			return nil, ErrConditionNotSupported
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if pre != nil {
+		//config center which checks preconditions reports the revision after write,
+		//a response without it means the precondition may have been ignored
+		if _, ok := field(resp, "revision"); !ok {
+			return resp, ErrConditionUnconfirmed
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,112 @@
+package configcenter_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chassis/go-chassis-config/pkg/configcenter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Revisions(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/default/configuration/revisions", r.URL.Path)
+		assert.Equal(t, "cart@default", r.URL.Query().Get("dimensionsInfo"))
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"revision":"7","keys":{"timeout":"5"}}`)
+	}))
+	defer s.Close()
+	c, err := configcenter.New(configcenter.Options{ConfigServerAddresses: []string{s.URL}})
+	assert.NoError(t, err)
+	r, err := c.Revisions("cart@default")
+	assert.NoError(t, err)
+	assert.Equal(t, &configcenter.Revisions{Revision: "7", Keys: map[string]string{"timeout": "5"}}, r)
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	c, err = configcenter.New(configcenter.Options{ConfigServerAddresses: []string{notFound.URL}})
+	assert.NoError(t, err)
+	_, err = c.Revisions("cart@default")
+	assert.Equal(t, configcenter.ErrConditionNotSupported, err)
+}
+
+func TestClient_AddConfigIf(t *testing.T) {
+	revision := "7"
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			io.WriteString(w, `{"revision":"`+revision+`"}`)
+			return
+		}
+		data := &configcenter.CreateConfigApi{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(data))
+		if data.If != nil && data.If.Revision != revision {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			io.WriteString(w, `{"expected":"`+data.If.Revision+`","current":"`+revision+`"}`)
+			return
+		}
+		revision = "8"
+		io.WriteString(w, `{"Result":"Success","revision":8}`)
+	}))
+	defer s.Close()
+	c, err := configcenter.New(configcenter.Options{ConfigServerAddresses: []string{s.URL}})
+	assert.NoError(t, err)
+
+	data := &configcenter.CreateConfigApi{DimensionInfo: "cart@default", Items: map[string]interface{}{"a": "1"},
+		If: &configcenter.Precondition{Revision: "7"}}
+	_, err = c.AddConfig(data)
+	assert.NoError(t, err)
+	_, err = c.AddConfig(data)
+	assert.Equal(t, &configcenter.ConflictError{Expected: "7", Current: "8"}, err)
+	_, err = c.DeleteConfig(&configcenter.DeleteConfigApi{DimensionInfo: "cart@default", Keys: []string{"a"},
+		If: &configcenter.Precondition{Revision: "7"}})
+	assert.IsType(t, &configcenter.ConflictError{}, err)
+}
+
+func TestClient_AddConfigIfNotSupported(t *testing.T) {
+	written := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			http.NotFound(w, r)
+			return
+		}
+		//the precondition is ignored like any unknown field
+		written++
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"Result":"Success"}`)
+	}))
+	defer s.Close()
+	c, err := configcenter.New(configcenter.Options{ConfigServerAddresses: []string{s.URL}})
+	assert.NoError(t, err)
+	_, err = c.AddConfig(&configcenter.CreateConfigApi{DimensionInfo: "cart@default", Items: map[string]interface{}{"a": "1"},
+		If: &configcenter.Precondition{Revision: "7"}})
+	assert.Equal(t, configcenter.ErrConditionNotSupported, err)
+	_, err = c.DeleteConfig(&configcenter.DeleteConfigApi{DimensionInfo: "cart@default", Keys: []string{"a"},
+		If: &configcenter.Precondition{Revision: "7"}})
+	assert.Equal(t, configcenter.ErrConditionNotSupported, err)
+	assert.Equal(t, 0, written)
+
+	_, err = c.AddConfig(&configcenter.CreateConfigApi{DimensionInfo: "cart@default", Items: map[string]interface{}{"a": "1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, written)
+}
+
+func TestClient_AddConfigIfUnconfirmed(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			io.WriteString(w, `{"revision":"7"}`)
+			return
+		}
+		io.WriteString(w, `{"Result":"Success"}`)
+	}))
+	defer s.Close()
+	c, err := configcenter.New(configcenter.Options{ConfigServerAddresses: []string{s.URL}})
+	assert.NoError(t, err)
+	_, err = c.AddConfig(&configcenter.CreateConfigApi{DimensionInfo: "cart@default", Items: map[string]interface{}{"a": "1"},
+		If: &configcenter.Precondition{Revision: "7"}})
+	assert.Equal(t, configcenter.ErrConditionUnconfirmed, err)
+}
@@ -20,11 +20,15 @@ package configcenter
 type DeleteConfigApi struct {
 	DimensionInfo string   `json:"dimensionsInfo"`
 	Keys          []string `json:"keys"`
+	//If is checked by config center before keys are deleted, nil means the delete is unconditional
+	If *Precondition `json:"if,omitempty"`
 }
 
 type CreateConfigApi struct {
 	DimensionInfo string                 `json:"dimensionsInfo"`
 	Items         map[string]interface{} `json:"items"`
+	//If is checked by config center before items are written, nil means the write is unconditional
+	If *Precondition `json:"if,omitempty"`
 }
 
 //ConfigCenterEvent stores info about an config center event
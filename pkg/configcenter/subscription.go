@@ -0,0 +1,164 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package configcenter
+
+import (
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Filter decides whether a subscription cares about a key, nil filter matches all keys
+type Filter func(key string) bool
+
+// MatchKeys matches the keys exactly
+func MatchKeys(keys ...string) Filter {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+	return func(key string) bool {
+		return set[key]
+	}
+}
+
+// MatchPrefix matches keys starting with prefix, like ratelimiter.
+func MatchPrefix(prefix string) Filter {
+	return func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	}
+}
+
+// MatchGlob matches keys with a shell pattern of path.Match, like cse.*.timeout
+func MatchGlob(pattern string) (Filter, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	return func(key string) bool {
+		ok, _ := path.Match(pattern, key)
+		return ok
+	}, nil
+}
+
+// MatchRegexp matches keys with a regular expression
+func MatchRegexp(expr string) (Filter, error) {
+	r, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return r.MatchString, nil
+}
+
+// Subscription receives events of keys matched by its filter
+type Subscription struct {
+	c          *Client
+	filter     Filter
+	f          func(e *Event, kv map[string]interface{})
+	errHandler func(err error)
+	//last is the configs delivered last time, only the dispatching goroutine accesses it
+	last map[string]interface{}
+}
+
+// Subscribe registers a subscription, f is called with configs of matched keys in every event,
+// a subscription with filter is called only if its configs changed, or its keys are deleted.
+// all subscriptions of a client share one websocket connection, it is connected by the first one
+func (c *Client) Subscribe(filter Filter, f func(e *Event, kv map[string]interface{}), errHandler func(err error)) (*Subscription, error) {
+	s := &Subscription{
+		c:          c,
+		filter:     filter,
+		f:          f,
+		errHandler: errHandler,
+	}
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	if !c.watching {
+		if err := c.dial(); err != nil {
+			return nil, err
+		}
+	}
+	c.subs = append(c.subs, s)
+	return s, nil
+}
+
+// Unsubscribe stops delivering events to the subscription, the connection is kept for others
+func (s *Subscription) Unsubscribe() {
+	s.c.subMu.Lock()
+	defer s.c.subMu.Unlock()
+	for i, sub := range s.c.subs {
+		if sub == s {
+			s.c.subs = append(s.c.subs[:i:i], s.c.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *Client) dispatch(e *Event, kv map[string]interface{}, err error) {
+	c.subMu.Lock()
+	subs := c.subs
+	c.subMu.Unlock()
+	for _, s := range subs {
+		if err != nil {
+			s.errHandler(err)
+			continue
+		}
+		s.receive(e, kv)
+	}
+}
+
+func (s *Subscription) receive(e *Event, kv map[string]interface{}) {
+	if s.filter == nil {
+		s.f(e, kv)
+		return
+	}
+	matched := make(map[string]interface{})
+	for k, v := range kv {
+		if s.filter(k) {
+			matched[k] = v
+		}
+	}
+	if strings.EqualFold(e.Action, "delete") {
+		//delete event carries deleted configs only
+		if len(matched) == 0 {
+			return
+		}
+		for k := range matched {
+			delete(s.last, k)
+		}
+		s.f(e, matched)
+		return
+	}
+	//other events may carry changed configs only, so they are merged into last
+	changed := false
+	for k, v := range matched {
+		if old, ok := s.last[k]; !ok || !reflect.DeepEqual(old, v) {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return
+	}
+	if s.last == nil {
+		s.last = make(map[string]interface{}, len(matched))
+	}
+	for k, v := range matched {
+		s.last[k] = v
+	}
+	s.f(e, matched)
+}
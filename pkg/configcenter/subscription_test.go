@@ -0,0 +1,93 @@
+package configcenter_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-chassis-config/pkg/configcenter"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	assert.True(t, configcenter.MatchKeys("a", "b")("b"))
+	assert.False(t, configcenter.MatchKeys("a")("ab"))
+	assert.True(t, configcenter.MatchPrefix("cse.flag.")("cse.flag.x"))
+	f, err := configcenter.MatchGlob("cse.*.timeout")
+	assert.NoError(t, err)
+	assert.True(t, f("cse.cart.timeout"))
+	assert.False(t, f("cse.cart.retry"))
+	_, err = configcenter.MatchGlob("[")
+	assert.Error(t, err)
+	f, err = configcenter.MatchRegexp(`^route\.\w+$`)
+	assert.NoError(t, err)
+	assert.True(t, f("route.cart"))
+	_, err = configcenter.MatchRegexp("(")
+	assert.Error(t, err)
+}
+
+func TestClient_Subscribe(t *testing.T) {
+	var conns int32
+	messages := make(chan map[string]interface{}, 4)
+	upgrader := websocket.Upgrader{}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		atomic.AddInt32(&conns, 1)
+		for kv := range messages {
+			v, _ := json.Marshal(kv)
+			b, _ := json.Marshal(&configcenter.Event{Action: "UPDATE", Value: string(v)})
+			conn.WriteMessage(websocket.TextMessage, b)
+		}
+	}))
+	defer s.Close()
+	u, _ := url.Parse(s.URL)
+	c, err := configcenter.New(configcenter.Options{
+		ConfigServerAddresses: []string{s.URL},
+		RefreshPort:           u.Port(),
+	})
+	assert.NoError(t, err)
+	defer c.Close()
+
+	limits := make(chan map[string]interface{}, 4)
+	flags := make(chan map[string]interface{}, 4)
+	_, err = c.Subscribe(configcenter.MatchPrefix("limit."), func(e *configcenter.Event, kv map[string]interface{}) {
+		limits <- kv
+	}, func(err error) {})
+	assert.NoError(t, err)
+	sub, err := c.Subscribe(configcenter.MatchKeys("flag"), func(e *configcenter.Event, kv map[string]interface{}) {
+		flags <- kv
+	}, func(err error) {})
+	assert.NoError(t, err)
+
+	messages <- map[string]interface{}{"limit.qps": "1", "flag": "on"}
+	messages <- map[string]interface{}{"limit.qps": "2", "flag": "on"}
+	assert.Equal(t, map[string]interface{}{"limit.qps": "1"}, receive(t, limits))
+	assert.Equal(t, map[string]interface{}{"limit.qps": "2"}, receive(t, limits))
+	assert.Equal(t, map[string]interface{}{"flag": "on"}, receive(t, flags))
+	sub.Unsubscribe()
+	messages <- map[string]interface{}{"limit.qps": "2", "flag": "off"}
+	messages <- map[string]interface{}{"limit.qps": "3"}
+	close(messages)
+	assert.Equal(t, map[string]interface{}{"limit.qps": "3"}, receive(t, limits))
+	assert.Empty(t, flags)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&conns))
+}
+
+func receive(t *testing.T, ch chan map[string]interface{}) map[string]interface{} {
+	select {
+	case kv := <-ch:
+		return kv
+	case <-time.After(5 * time.Second):
+		t.Fatal("no config received")
+	}
+	return nil
+}
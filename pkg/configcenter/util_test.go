@@ -25,4 +25,8 @@ func TestGetConfigs(t *testing.T) {
 	m2, err := configcenter.GetConfigs(b)
 	assert.NoError(t, err)
 	assert.Equal(t, "b", m2["a"])
+	e2, m3, err := configcenter.DecodeEvent(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "delete", e2.Action)
+	assert.Equal(t, m2, m3)
 }
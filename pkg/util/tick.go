@@ -0,0 +1,38 @@
+package util
+
+import "time"
+
+// Tick is like time.Tick, but it stops after done is closed, then the channel is closed
+func Tick(d time.Duration, done <-chan struct{}) <-chan time.Time {
+	ch := make(chan time.Time)
+	go func() {
+		t := time.NewTicker(d)
+		defer t.Stop()
+		defer close(ch)
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-t.C:
+				select {
+				case ch <- now:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+	return ch
+}
+
+// Sleep pauses for d, it returns false if done is closed before d elapsed
+func Sleep(d time.Duration, done <-chan struct{}) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-done:
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"fmt"
 	"sort"
 )
 
@@ -23,3 +24,28 @@ func Map2String(m map[string]string) string {
 	}
 	return result
 }
+
+//Flatten convert a nested document, for example decoded from yaml or json,
+//to a flat map whose keys are joined by dot, like a.b.c
+func Flatten(m map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	for k, v := range m {
+		flatten(k, v, result)
+	}
+	return result
+}
+
+func flatten(prefix string, v interface{}, result map[string]interface{}) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, sub := range value {
+			flatten(prefix+"."+k, sub, result)
+		}
+	case map[interface{}]interface{}:
+		for k, sub := range value {
+			flatten(prefix+"."+fmt.Sprint(k), sub, result)
+		}
+	default:
+		result[prefix] = v
+	}
+}
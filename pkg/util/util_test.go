@@ -1,8 +1,11 @@
 package util_test
 
 import (
-	"github.com/go-chassis/go-chassis-config/pkg/util"
 	"testing"
+	"time"
+
+	"github.com/go-chassis/go-chassis-config/pkg/util"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestMap2String(t *testing.T) {
@@ -12,3 +15,26 @@ func TestMap2String(t *testing.T) {
 	m["d"] = "b"
 	t.Log(util.Map2String(m))
 }
+
+func TestFlatten(t *testing.T) {
+	m := map[string]interface{}{
+		"a": map[string]interface{}{"b": 1},
+		"c": map[interface{}]interface{}{"d": map[interface{}]interface{}{"e": "f"}},
+		"g": []interface{}{1, 2},
+	}
+	f := util.Flatten(m)
+	if f["a.b"] != 1 || f["c.d.e"] != "f" || len(f) != 3 {
+		t.Errorf("unexpected result %v", f)
+	}
+}
+
+func TestTick(t *testing.T) {
+	done := make(chan struct{})
+	ch := util.Tick(time.Millisecond, done)
+	<-ch
+	close(done)
+	for range ch {
+	}
+	assert.False(t, util.Sleep(time.Minute, done))
+	assert.True(t, util.Sleep(time.Millisecond, make(chan struct{})))
+}
@@ -0,0 +1,107 @@
+package redis_test
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/go-chassis/go-chassis-config/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient(t *testing.T) {
+	s, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer s.Close()
+
+	c, err := config.NewClient(redis.Name, config.Options{
+		ServerURI: s.Addr(),
+		Labels:    map[string]string{config.LabelApp: "mall", config.LabelService: "cart"},
+	})
+	assert.NoError(t, err)
+	defer c.(io.Closer).Close()
+	assert.Equal(t, "config:app=mall|serviceName=cart", c.(*redis.Client).Key(nil))
+
+	events := make(chan map[string]interface{}, 10)
+	err = c.Watch(func(m map[string]interface{}) {
+		events <- m
+	}, func(err error) {
+		t.Log(err)
+	}, nil)
+	assert.NoError(t, err)
+
+	_, err = c.PushConfigs(map[string]interface{}{"timeout": "1s", "retry": 3}, nil)
+	assert.NoError(t, err)
+	select {
+	case m := <-events:
+		assert.Equal(t, "1s", m["timeout"])
+		assert.Equal(t, "3", m["retry"])
+	case <-time.After(3 * time.Second):
+		t.Fatal("no event received")
+	}
+
+	m, err := c.PullConfigs()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(m))
+	l, ok := config.AsLister(c)
+	assert.True(t, ok)
+	keys, err := l.ListKeys(nil)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	_, err = c.DeleteConfigsByKeys([]string{"timeout"}, nil)
+	assert.NoError(t, err)
+	select {
+	case m := <-events:
+		assert.Nil(t, m["timeout"])
+	case <-time.After(3 * time.Second):
+		t.Fatal("no event received")
+	}
+	_, err = c.PullConfig("timeout", "", nil)
+	assert.Equal(t, config.ErrKeyNotExist, err)
+	v, err := c.PullConfig("retry", "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "3", v)
+
+	//other label sets are not affected
+	m, err = c.PullConfigs(map[string]string{config.LabelApp: "mall"})
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+}
+
+func TestClient_WatchReconnect(t *testing.T) {
+	s, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer s.Close()
+
+	c, err := config.NewClient(redis.Name, config.Options{
+		ServerURI: s.Addr(),
+		Labels:    map[string]string{config.LabelApp: "mall", config.LabelService: "cart"},
+	})
+	assert.NoError(t, err)
+	events := make(chan map[string]interface{}, 10)
+	err = c.Watch(func(m map[string]interface{}) {
+		events <- m
+	}, func(err error) {
+		t.Log(err)
+	}, nil)
+	assert.NoError(t, err)
+	_, err = c.PushConfigs(map[string]interface{}{"timeout": "1s"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "1s", (<-events)["timeout"])
+
+	//the change is not published while the subscription is broken
+	s.Close()
+	s.HSet(c.(*redis.Client).Key(nil), "timeout", "2s")
+	assert.NoError(t, s.Restart())
+	select {
+	case m := <-events:
+		assert.Equal(t, "2s", m["timeout"])
+	case <-time.After(10 * time.Second):
+		t.Fatal("change during the outage is not delivered")
+	}
+
+	assert.NoError(t, c.(io.Closer).Close())
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/go-mesh/openlogging"
+)
+
+// ErrPluginExists means a plugin with the same name is registered
+var ErrPluginExists = errors.New("plugin already exists")
+
+// PluginInfo is the metadata of a plugin
+type PluginInfo struct {
+	Name         string
+	Capabilities []Capability
+}
+
+// Supports reports whether plugin has the capability
+func (p PluginInfo) Supports(c Capability) bool {
+	for _, pc := range p.Capabilities {
+		if pc == c {
+			return true
+		}
+	}
+	return false
+}
+
+type plugin struct {
+	info PluginInfo
+	f    func(options Options) (Client, error)
+}
+
+var (
+	pluginsMu           sync.RWMutex
+	configClientPlugins = make(map[string]plugin)
+)
+
+// RegisterPlugin registers a config client plugin with its capabilities,
+// it returns ErrPluginExists if the name is registered
+func RegisterPlugin(name string, f func(options Options) (Client, error), capabilities ...Capability) error {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	if _, ok := configClientPlugins[name]; ok {
+		return ErrPluginExists
+	}
+	configClientPlugins[name] = plugin{info: PluginInfo{Name: name, Capabilities: capabilities}, f: f}
+	openlogging.GetLogger().Infof("Installed %s Plugin", name)
+	return nil
+}
+
+// InstallConfigClientPlugin install a config client plugin, it replaces the plugin with the same name
+func InstallConfigClientPlugin(name string, f func(options Options) (Client, error), capabilities ...Capability) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	if _, ok := configClientPlugins[name]; ok {
+		openlogging.GetLogger().Warnf("%s Plugin is replaced", name)
+	}
+	configClientPlugins[name] = plugin{info: PluginInfo{Name: name, Capabilities: capabilities}, f: f}
+	openlogging.GetLogger().Infof("Installed %s Plugin", name)
+}
+
+// UnregisterPlugin removes a plugin, it returns false if the plugin is not registered
+func UnregisterPlugin(name string) bool {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	if _, ok := configClientPlugins[name]; !ok {
+		return false
+	}
+	delete(configClientPlugins, name)
+	return true
+}
+
+// GetPluginInfo returns the metadata of a plugin
+func GetPluginInfo(name string) (PluginInfo, bool) {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	p, ok := configClientPlugins[name]
+	return p.info, ok
+}
+
+// ListPlugins returns metadata of all plugins sorted by name
+func ListPlugins() []PluginInfo {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	infos := make([]PluginInfo, 0, len(configClientPlugins))
+	for _, p := range configClientPlugins {
+		infos = append(infos, p.info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+func getPlugin(name string) func(options Options) (Client, error) {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	return configClientPlugins[name].f
+}
@@ -0,0 +1,50 @@
+package config_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/stretchr/testify/assert"
+)
+
+func newNilClient(options config.Options) (config.Client, error) {
+	return nil, nil
+}
+
+func TestRegistry(t *testing.T) {
+	err := config.RegisterPlugin("test_registry", newNilClient, config.CapabilityWatch)
+	assert.NoError(t, err)
+	err = config.RegisterPlugin("test_registry", newNilClient)
+	assert.Equal(t, config.ErrPluginExists, err)
+
+	info, ok := config.GetPluginInfo("test_registry")
+	assert.True(t, ok)
+	assert.True(t, info.Supports(config.CapabilityWatch))
+	assert.False(t, info.Supports(config.CapabilityWrite))
+
+	config.InstallConfigClientPlugin("test_registry", newNilClient, config.CapabilityWrite)
+	info, _ = config.GetPluginInfo("test_registry")
+	assert.True(t, info.Supports(config.CapabilityWrite))
+	assert.Contains(t, config.ListPlugins(), info)
+
+	ccInfo, ok := config.GetPluginInfo("config_center")
+	assert.True(t, ok)
+	assert.True(t, ccInfo.Supports(config.CapabilityWrite))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			config.ListPlugins()
+			config.GetPluginInfo("test_registry")
+		}()
+	}
+	wg.Wait()
+
+	assert.True(t, config.UnregisterPlugin("test_registry"))
+	assert.False(t, config.UnregisterPlugin("test_registry"))
+	_, err = config.NewClient("test_registry", config.Options{})
+	assert.Error(t, err)
+}
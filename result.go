@@ -0,0 +1,79 @@
+package config
+
+// KeyStatus is the status of a key in a write
+type KeyStatus string
+
+// key statuses
+const (
+	KeyStatusSuccess KeyStatus = "success"
+	KeyStatusFailed  KeyStatus = "failed"
+)
+
+// WriteResult is the typed result of a write
+type WriteResult struct {
+	// Success is true if the write is accepted
+	Success bool
+	// Keys holds the status of each pushed or deleted key,
+	// plugins which accept or reject a write as a whole give each key the status of the write
+	Keys map[string]KeyStatus
+	// Message is the result message reported by plugin, it is empty if plugin does not report it
+	Message string
+	// Revision is the revision after write, it is empty if plugin does not report it
+	Revision string
+	// Warnings are reported by plugin
+	Warnings []string
+	// Raw is the untyped result returned by plugin
+	Raw map[string]interface{}
+}
+
+// ResultWriter is implemented by clients which decode results of writes
+type ResultWriter interface {
+	PushConfigsResult(items map[string]interface{}, labels map[string]string) (*WriteResult, error)
+	DeleteConfigsByKeysResult(keys []string, labels map[string]string) (*WriteResult, error)
+}
+
+// PushConfigsResult pushes items and returns the typed result, it uses ResultWriter if client implements it,
+// or else a write without error is reported as success of all items and the untyped result is kept in Raw
+func PushConfigsResult(c Client, items map[string]interface{}, labels map[string]string) (*WriteResult, error) {
+	if w, ok := c.(ResultWriter); ok && Supports(c, CapabilityWrite) {
+		return w.PushConfigsResult(items, labels)
+	}
+	w, ok := AsWriter(c)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	raw, err := w.PushConfigs(items, labels)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+	return writeResult(keys, raw), nil
+}
+
+// DeleteConfigsByKeysResult deletes keys and returns the typed result, like PushConfigsResult
+func DeleteConfigsByKeysResult(c Client, keys []string, labels map[string]string) (*WriteResult, error) {
+	if w, ok := c.(ResultWriter); ok && Supports(c, CapabilityWrite) {
+		return w.DeleteConfigsByKeysResult(keys, labels)
+	}
+	w, ok := AsWriter(c)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	raw, err := w.DeleteConfigsByKeys(keys, labels)
+	if err != nil {
+		return nil, err
+	}
+	return writeResult(keys, raw), nil
+}
+
+// writeResult reports a write of keys without error as success
+func writeResult(keys []string, raw map[string]interface{}) *WriteResult {
+	r := &WriteResult{Success: true, Keys: make(map[string]KeyStatus, len(keys)), Raw: raw}
+	for _, k := range keys {
+		r.Keys[k] = KeyStatusSuccess
+	}
+	return r
+}
@@ -0,0 +1,52 @@
+package config_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/stretchr/testify/assert"
+)
+
+type resultClient struct {
+	memClient
+}
+
+func (c *resultClient) PushConfigsResult(items map[string]interface{}, labels map[string]string) (*config.WriteResult, error) {
+	r := &config.WriteResult{Message: "rejected", Keys: map[string]config.KeyStatus{}, Warnings: []string{"read only"}}
+	for k := range items {
+		r.Keys[k] = config.KeyStatusFailed
+	}
+	return r, nil
+}
+
+func (c *resultClient) DeleteConfigsByKeysResult(keys []string, labels map[string]string) (*config.WriteResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestPushConfigsResult(t *testing.T) {
+	c := &memClient{kv: map[string]interface{}{}}
+	r, err := config.PushConfigsResult(c, map[string]interface{}{"a": "1", "b": "2"}, nil)
+	assert.NoError(t, err)
+	assert.True(t, r.Success)
+	assert.Equal(t, map[string]config.KeyStatus{"a": config.KeyStatusSuccess, "b": config.KeyStatusSuccess}, r.Keys)
+	assert.Equal(t, "1", c.kv["a"])
+	r, err = config.DeleteConfigsByKeysResult(c, []string{"a", "b"}, nil)
+	assert.NoError(t, err)
+	assert.True(t, r.Success)
+	assert.Equal(t, map[string]config.KeyStatus{"a": config.KeyStatusSuccess, "b": config.KeyStatusSuccess}, r.Keys)
+	assert.Empty(t, c.kv)
+
+	rc := &resultClient{memClient{kv: map[string]interface{}{}}}
+	r, err = config.PushConfigsResult(rc, map[string]interface{}{"a": "1"}, nil)
+	assert.NoError(t, err)
+	assert.False(t, r.Success)
+	assert.Equal(t, "rejected", r.Message)
+	assert.Equal(t, map[string]config.KeyStatus{"a": config.KeyStatusFailed}, r.Keys)
+	assert.Empty(t, rc.kv)
+	_, err = config.DeleteConfigsByKeysResult(rc, []string{"a"}, nil)
+	assert.EqualError(t, err, "not implemented")
+
+	_, err = config.PushConfigsResult(&readOnlyClient{c}, map[string]interface{}{"a": "1"}, nil)
+	assert.Equal(t, config.ErrNotSupported, err)
+}
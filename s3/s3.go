@@ -0,0 +1,457 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package s3 is the config client plugin of s3 compatible object storage, like aws s3 and minio.
+// each object under the prefix of labels is a config, object name is the key and object content is the value
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chassis/foundation/httpclient"
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/go-chassis/go-chassis-config/pkg/util"
+	"github.com/go-chassis/go-chassis-config/serializers"
+	"github.com/go-mesh/openlogging"
+)
+
+const (
+	// Name of the Plugin
+	Name = "s3"
+	//LabelPath is the label of object prefix, if it is absent,
+	//prefix is joined by app, environment, serviceName and version labels
+	LabelPath = "path"
+
+	//ParamBucket is the bucket of config objects
+	ParamBucket = "bucket"
+	//ParamRoot is prepended to the prefix of labels, default is empty
+	ParamRoot = "root"
+	//ParamRegion is the region of signature, default is us-east-1
+	ParamRegion = "region"
+	//ParamAccessKey is the access key id
+	ParamAccessKey = "accessKey"
+	//ParamSecretKey is the secret access key
+	ParamSecretKey = "secretKey"
+	//ParamSessionToken is the session token of temporary credentials
+	ParamSessionToken = "sessionToken"
+	//ParamTimeout is the timeout of each request, like 10s, default is 30s
+	ParamTimeout = "timeout"
+
+	contentTypeText      = "text/plain"
+	defaultRegion        = "us-east-1"
+	defaultWatchDuration = 30 * time.Second
+	defaultTimeout       = 30 * time.Second
+)
+
+// errors
+var (
+	ErrInvalidEP   = errors.New("invalid endpoint")
+	ErrBucketEmpty = errors.New("params bucket can not be empty")
+	ErrPrefixEmpty = errors.New("object prefix can not be empty")
+	errNotFound    = errors.New("object not found")
+)
+
+// Object is the summary of an object in list result
+type Object struct {
+	Key  string `xml:"Key"`
+	ETag string `xml:"ETag"`
+}
+
+// ListBucketResult is the result of ListObjectsV2
+type ListBucketResult struct {
+	Contents              []Object `xml:"Contents"`
+	IsTruncated           bool     `xml:"IsTruncated"`
+	NextContinuationToken string   `xml:"NextContinuationToken"`
+}
+
+// Client is s3 config client implementation, it uses path style requests
+type Client struct {
+	opts     config.Options
+	c        *httpclient.Requests
+	endpoint *url.URL
+	bucket   string
+	region   string
+	creds    Credentials
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewClient create s3 config client, ServerURI is the endpoint like https://s3.amazonaws.com or http://127.0.0.1:9000
+func NewClient(options config.Options) (config.Client, error) {
+	if options.ServerURI == "" {
+		return nil, ErrInvalidEP
+	}
+	ep, err := url.Parse(strings.TrimRight(options.ServerURI, "/"))
+	if err != nil || ep.Host == "" {
+		return nil, ErrInvalidEP
+	}
+	bucket := options.Params[ParamBucket]
+	if bucket == "" {
+		return nil, ErrBucketEmpty
+	}
+	timeout := defaultTimeout
+	if t := options.Params[ParamTimeout]; t != "" {
+		if timeout, err = time.ParseDuration(t); err != nil {
+			return nil, err
+		}
+	}
+	c := &Client{
+		opts:     options,
+		endpoint: ep,
+		bucket:   bucket,
+		region:   options.Params[ParamRegion],
+		creds: Credentials{
+			AccessKey:    options.Params[ParamAccessKey],
+			SecretKey:    options.Params[ParamSecretKey],
+			SessionToken: options.Params[ParamSessionToken],
+		},
+	}
+	hc, err := httpclient.New(&httpclient.Options{
+		SSLEnabled:     options.EnableSSL,
+		TLSConfig:      options.TLSConfig,
+		RequestTimeout: timeout,
+		SignRequest:    c.sign,
+	})
+	if err != nil {
+		return nil, err
+	}
+	//request timeout is only set for ssl client
+	hc.Timeout = timeout
+	c.c = hc
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	if c.region == "" {
+		c.region = defaultRegion
+	}
+	openlogging.Info("new s3 client", openlogging.WithTags(
+		openlogging.Tags{
+			"ep":      options.ServerURI,
+			"bucket":  bucket,
+			"ssl":     options.EnableSSL,
+			"timeout": timeout.String(),
+		}))
+	return c, nil
+}
+
+// Prefix returns the object prefix of labels, it ends with slash
+func (c *Client) Prefix(labels map[string]string) (string, error) {
+	if len(labels) == 0 {
+		labels = c.opts.Labels
+	}
+	elements := make([]string, 0, 5)
+	if r := strings.Trim(c.opts.Params[ParamRoot], "/"); r != "" {
+		elements = append(elements, r)
+	}
+	if p := strings.Trim(labels[LabelPath], "/"); p != "" {
+		elements = append(elements, p)
+	} else {
+		for _, l := range []string{config.LabelApp, config.LabelEnvironment, config.LabelService, config.LabelVersion} {
+			if labels[l] != "" {
+				elements = append(elements, labels[l])
+			}
+		}
+	}
+	if len(elements) == 0 {
+		return "", ErrPrefixEmpty
+	}
+	return strings.Join(elements, "/") + "/", nil
+}
+
+// do sends a signed request of the object, empty object means bucket
+func (c *Client) do(method, object string, query url.Values, header http.Header, body []byte) (*http.Response, []byte, error) {
+	p := "/" + c.bucket + "/" + object
+	u := *c.endpoint
+	u.Path = c.endpoint.Path + p
+	u.RawPath = escape(c.endpoint.Path, true) + escape(p, true)
+	u.RawQuery = canonicalQuery(query)
+	resp, err := c.c.Do(c.ctx, method, u.String(), header.Clone(), body)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound && object != "" {
+		return resp, b, errNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, b, fmt.Errorf("%s %s failed, statusCode: %d, resp body: %s", method, p, resp.StatusCode, b)
+	}
+	return resp, b, nil
+}
+
+// sign signs a request if access key is set
+func (c *Client) sign(req *http.Request) error {
+	if c.creds.AccessKey == "" {
+		return nil
+	}
+	var body []byte
+	if req.GetBody != nil {
+		r, err := req.GetBody()
+		if err != nil {
+			return err
+		}
+		if body, err = ioutil.ReadAll(r); err != nil {
+			return err
+		}
+	}
+	Sign(req, PayloadHash(body), c.creds, c.region, time.Now())
+	return nil
+}
+
+// List returns etags of objects under prefix, keys are object names without prefix
+func (c *Client) List(prefix string) (map[string]string, error) {
+	etags := make(map[string]string)
+	token := ""
+	for {
+		q := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		_, b, err := c.do(http.MethodGet, "", q, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		result := &ListBucketResult{}
+		if err := xml.Unmarshal(b, result); err != nil {
+			return nil, err
+		}
+		for _, o := range result.Contents {
+			k := strings.TrimPrefix(o.Key, prefix)
+			if k == "" || strings.HasSuffix(k, "/") {
+				continue
+			}
+			etags[k] = o.ETag
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return etags, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// get returns the decoded content of object, json objects are decoded, others are string
+func (c *Client) get(object string) (interface{}, error) {
+	resp, b, err := c.do(http.MethodGet, object, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), serializers.JsonEncoder) {
+		var v interface{}
+		if err := serializers.Decode(serializers.JsonEncoder, b, &v); err != nil {
+			return nil, fmt.Errorf("decode %s failed: %s", object, err)
+		}
+		return v, nil
+	}
+	return string(b), nil
+}
+
+// read gets objects of etags, objects not changed since last are reused
+func (c *Client) read(prefix string, etags, lastETags map[string]string, last map[string]interface{}) (map[string]interface{}, error) {
+	kv := make(map[string]interface{}, len(etags))
+	for k, etag := range etags {
+		if v, ok := last[k]; ok && lastETags[k] == etag {
+			kv[k] = v
+			continue
+		}
+		v, err := c.get(prefix + k)
+		if err == errNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		kv[k] = v
+	}
+	return kv, nil
+}
+
+// PullConfigs returns all objects under the prefix of labels
+func (c *Client) PullConfigs(labels ...map[string]string) (map[string]interface{}, error) {
+	var l map[string]string
+	if len(labels) != 0 {
+		l = labels[0]
+	}
+	prefix, err := c.Prefix(l)
+	if err != nil {
+		return nil, err
+	}
+	etags, err := c.List(prefix)
+	if err != nil {
+		return nil, err
+	}
+	return c.read(prefix, etags, nil, nil)
+}
+
+// ListKeys returns object names under the prefix of labels
+func (c *Client) ListKeys(labels map[string]string) ([]string, error) {
+	prefix, err := c.Prefix(labels)
+	if err != nil {
+		return nil, err
+	}
+	etags, err := c.List(prefix)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(etags))
+	for k := range etags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// PullConfig returns one object under the prefix of labels
+func (c *Client) PullConfig(key, contentType string, labels map[string]string) (interface{}, error) {
+	prefix, err := c.Prefix(labels)
+	if err != nil {
+		return nil, err
+	}
+	v, err := c.get(prefix + key)
+	if err == errNotFound {
+		return nil, config.ErrKeyNotExist
+	}
+	return v, err
+}
+
+// PushConfigs puts one object for each item, strings are stored as text, others are stored as json,
+// it returns etags of objects
+func (c *Client) PushConfigs(items map[string]interface{}, labels map[string]string) (map[string]interface{}, error) {
+	if len(items) == 0 {
+		return nil, errors.New("data is empty, nothing to push")
+	}
+	prefix, err := c.Prefix(labels)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]interface{}, len(items))
+	for k, v := range items {
+		var b []byte
+		contentType := contentTypeText
+		switch value := v.(type) {
+		case string:
+			b = []byte(value)
+		case []byte:
+			b = value
+		default:
+			if b, err = serializers.Encode(serializers.JsonEncoder, v); err != nil {
+				return result, err
+			}
+			contentType = serializers.JsonEncoder
+		}
+		resp, _, err := c.do(http.MethodPut, prefix+k, nil, http.Header{"Content-Type": {contentType}}, b)
+		if err != nil {
+			return result, err
+		}
+		result[k] = resp.Header.Get("ETag")
+	}
+	return result, nil
+}
+
+// DeleteConfigsByKeys deletes objects of keys
+func (c *Client) DeleteConfigsByKeys(keys []string, labels map[string]string) (map[string]interface{}, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no key need to delete, please check keys")
+	}
+	prefix, err := c.Prefix(labels)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]interface{}, len(keys))
+	for _, k := range keys {
+		if _, _, err := c.do(http.MethodDelete, prefix+k, nil, nil, nil); err != nil && err != errNotFound {
+			return result, err
+		}
+		result[k] = "deleted"
+	}
+	return result, nil
+}
+
+// Watch lists objects periodically, changed objects are detected by etag,
+// it calls f with all objects after any of them changed
+func (c *Client) Watch(f func(map[string]interface{}), errHandler func(err error), labels map[string]string) error {
+	prefix, err := c.Prefix(labels)
+	if err != nil {
+		return err
+	}
+	lastETags, err := c.List(prefix)
+	if err != nil {
+		return err
+	}
+	last, err := c.read(prefix, lastETags, nil, nil)
+	if err != nil {
+		return err
+	}
+	d := c.opts.WatchDuration
+	if d <= 0 {
+		d = defaultWatchDuration
+	}
+	go func() {
+		for range util.Tick(d, c.ctx.Done()) {
+			etags, err := c.List(prefix)
+			if err != nil {
+				if c.ctx.Err() != nil {
+					return
+				}
+				errHandler(err)
+				continue
+			}
+			if reflect.DeepEqual(etags, lastETags) {
+				continue
+			}
+			kv, err := c.read(prefix, etags, lastETags, last)
+			if err != nil {
+				errHandler(err)
+				continue
+			}
+			lastETags = etags
+			if reflect.DeepEqual(kv, last) {
+				continue
+			}
+			last = kv
+			f(kv)
+		}
+	}()
+	return nil
+}
+
+// Close stops watches and cancels requests in flight
+func (c *Client) Close() error {
+	c.cancel()
+	return nil
+}
+
+// Options return settings
+func (c *Client) Options() config.Options {
+	return c.opts
+}
+
+func init() {
+	config.InstallConfigClientPlugin(Name, NewClient, config.CapabilityRead, config.CapabilityWrite, config.CapabilityWatch, config.CapabilityList)
+}
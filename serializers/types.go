@@ -20,11 +20,14 @@ package serializers
 import (
 	"errors"
 	"github.com/go-chassis/go-chassis-config/serializers/json"
+	"github.com/go-chassis/go-chassis-config/serializers/yaml"
 )
 
 const (
 	//JsonEncoder is a variable of type string
 	JsonEncoder = `application/json`
+	//YamlEncoder is a variable of type string
+	YamlEncoder = `application/yaml`
 )
 
 var availableSerializers map[string]Serializer
@@ -36,10 +39,12 @@ type Serializer interface {
 }
 
 var _ Serializer = json.JsonSerializer{}
+var _ Serializer = yaml.YamlSerializer{}
 
 func init() {
 	availableSerializers = make(map[string]Serializer)
 	availableSerializers[JsonEncoder] = json.JsonSerializer{}
+	availableSerializers[YamlEncoder] = yaml.YamlSerializer{}
 }
 
 // Encode is a convenience wrapper for encoding to a []byte from an Encoder
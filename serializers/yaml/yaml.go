@@ -0,0 +1,36 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+//Package yaml is used for marshalling and unmarshalling yaml documents
+package yaml
+
+import (
+	"gopkg.in/yaml.v2"
+)
+
+//YamlSerializer is a empty struct
+type YamlSerializer struct{}
+
+//Decode - Unmarshal unmarshaling data
+func (ys YamlSerializer) Decode(data []byte, v interface{}) error {
+	return yaml.Unmarshal(data, v)
+}
+
+//Encode - Marshal marshaling data
+func (ys YamlSerializer) Encode(v interface{}) ([]byte, error) {
+	return yaml.Marshal(v)
+}
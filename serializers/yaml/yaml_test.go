@@ -0,0 +1,21 @@
+package yaml
+
+import (
+	"testing"
+)
+
+type Test struct {
+	Team string `yaml:"team"`
+}
+
+func TestEncodeDecode(t *testing.T) {
+	s := &YamlSerializer{}
+	b, err := s.Encode(&Test{Team: "data"})
+	if err != nil {
+		t.Error("error in encoding")
+	}
+	test := &Test{}
+	if err = s.Decode(b, test); err != nil || test.Team != "data" {
+		t.Error("error in decoding")
+	}
+}
@@ -0,0 +1,479 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package sql is the config client plugin of relational databases.
+// it uses database/sql, import the driver of your database along with this package
+package sql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/go-chassis/go-chassis-config/pkg/util"
+	"github.com/go-chassis/go-chassis-config/serializers"
+	"github.com/go-mesh/openlogging"
+)
+
+const (
+	// Name of the Plugin
+	Name = "sql"
+	//ParamDriver is the registered name of database/sql driver, like mysql, postgres or sqlite3
+	ParamDriver = "driver"
+
+	contentTypeText      = "text/plain"
+	defaultWatchDuration = 30 * time.Second
+)
+
+// Schema is the tables used by this plugin, it works on mysql, postgres and sqlite.
+// config_item holds one row per key of a label set,
+// the label set is app, service, version and environment, absent labels are empty string.
+// config_revision holds the revision of a label set, it is bumped by every write of the label set
+const Schema = `
+CREATE TABLE IF NOT EXISTS config_item (
+	app          VARCHAR(128) NOT NULL DEFAULT '',
+	service      VARCHAR(128) NOT NULL DEFAULT '',
+	version      VARCHAR(64)  NOT NULL DEFAULT '',
+	environment  VARCHAR(64)  NOT NULL DEFAULT '',
+	item_key     VARCHAR(255) NOT NULL,
+	item_value   TEXT,
+	content_type VARCHAR(64)  NOT NULL DEFAULT 'text/plain',
+	revision     BIGINT       NOT NULL,
+	updated_at   TIMESTAMP    NOT NULL,
+	PRIMARY KEY (app, service, version, environment, item_key)
+);
+CREATE TABLE IF NOT EXISTS config_revision (
+	app          VARCHAR(128) NOT NULL DEFAULT '',
+	service      VARCHAR(128) NOT NULL DEFAULT '',
+	version      VARCHAR(64)  NOT NULL DEFAULT '',
+	environment  VARCHAR(64)  NOT NULL DEFAULT '',
+	revision     BIGINT       NOT NULL,
+	updated_at   TIMESTAMP    NOT NULL,
+	PRIMARY KEY (app, service, version, environment)
+);
+`
+
+const labelFilter = "app = ? AND service = ? AND version = ? AND environment = ?"
+
+// errors
+var (
+	ErrInvalidEP     = errors.New("invalid data source name")
+	ErrDriverMissing = errors.New("params driver can not be empty")
+)
+
+// Client is database config client implementation
+type Client struct {
+	opts   config.Options
+	db     *sql.DB
+	dollar bool
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewClient opens the database and create database config client, ServerURI is the data source name
+func NewClient(options config.Options) (config.Client, error) {
+	if options.ServerURI == "" {
+		return nil, ErrInvalidEP
+	}
+	driver := options.Params[ParamDriver]
+	if driver == "" {
+		return nil, ErrDriverMissing
+	}
+	db, err := sql.Open(driver, options.ServerURI)
+	if err != nil {
+		return nil, err
+	}
+	openlogging.Info("new sql client", openlogging.WithTags(
+		openlogging.Tags{
+			"driver": driver,
+		}))
+	return New(db, driver, options), nil
+}
+
+// New create database config client with an opened database
+func New(db *sql.DB, driver string, options config.Options) *Client {
+	return &Client{
+		opts:   options,
+		db:     db,
+		dollar: driver == "postgres" || driver == "pgx",
+		done:   make(chan struct{}),
+	}
+}
+
+// rebind converts ? placeholders to $n for postgres
+func (c *Client) rebind(query string) string {
+	if !c.dollar {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// labelArgs returns values of label columns
+func (c *Client) labelArgs(labels map[string]string) []interface{} {
+	if len(labels) == 0 {
+		labels = c.opts.Labels
+	}
+	return []interface{}{labels[config.LabelApp], labels[config.LabelService],
+		labels[config.LabelVersion], labels[config.LabelEnvironment]}
+}
+
+func encode(v interface{}) (string, string, error) {
+	switch value := v.(type) {
+	case string:
+		return value, contentTypeText, nil
+	case []byte:
+		return string(value), contentTypeText, nil
+	}
+	b, err := serializers.Encode(serializers.JsonEncoder, v)
+	return string(b), serializers.JsonEncoder, err
+}
+
+func decode(value, contentType string) (interface{}, error) {
+	if contentType != serializers.JsonEncoder {
+		return value, nil
+	}
+	var v interface{}
+	err := serializers.Decode(serializers.JsonEncoder, []byte(value), &v)
+	return v, err
+}
+
+func (c *Client) read(args []interface{}, key string) (map[string]interface{}, error) {
+	query := "SELECT item_key, item_value, content_type FROM config_item WHERE " + labelFilter
+	if key != "" {
+		query += " AND item_key = ?"
+		args = append(args, key)
+	}
+	rows, err := c.db.Query(c.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	kv := make(map[string]interface{})
+	for rows.Next() {
+		var k, value, contentType string
+		if err := rows.Scan(&k, &value, &contentType); err != nil {
+			return nil, err
+		}
+		if kv[k], err = decode(value, contentType); err != nil {
+			return nil, fmt.Errorf("decode %s failed: %s", k, err)
+		}
+	}
+	return kv, rows.Err()
+}
+
+// Revision returns the revision of labels, it is 0 if labels has never been written
+func (c *Client) Revision(labels map[string]string) (int64, error) {
+	return c.revision(c.db, c.labelArgs(labels))
+}
+
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func (c *Client) revision(q queryer, args []interface{}) (int64, error) {
+	var r int64
+	err := q.QueryRow(c.rebind("SELECT revision FROM config_revision WHERE "+labelFilter), args...).Scan(&r)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return r, err
+}
+
+// revisions returns the revision of label set and revisions of its keys, revision of a key is the revision of its last write
+func (c *Client) revisions(q queryer, args []interface{}, revision int64) (*config.Revisions, error) {
+	rows, err := q.Query(c.rebind("SELECT item_key, revision FROM config_item WHERE "+labelFilter), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	r := &config.Revisions{Revision: strconv.FormatInt(revision, 10), Keys: make(map[string]string)}
+	for rows.Next() {
+		var k string
+		var v int64
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, err
+		}
+		r.Keys[k] = strconv.FormatInt(v, 10)
+	}
+	return r, rows.Err()
+}
+
+// Revisions returns the revision of label set and revisions of its keys
+func (c *Client) Revisions(labels map[string]string) (*config.Revisions, error) {
+	args := c.labelArgs(labels)
+	revision, err := c.revision(c.db, args)
+	if err != nil {
+		return nil, err
+	}
+	return c.revisions(c.db, args, revision)
+}
+
+// PullConfigs returns all keys of the label set
+func (c *Client) PullConfigs(labels ...map[string]string) (map[string]interface{}, error) {
+	var l map[string]string
+	if len(labels) != 0 {
+		l = labels[0]
+	}
+	return c.read(c.labelArgs(l), "")
+}
+
+// ListKeys returns keys of the label set
+func (c *Client) ListKeys(labels map[string]string) ([]string, error) {
+	rows, err := c.db.Query(c.rebind("SELECT item_key FROM config_item WHERE "+labelFilter+" ORDER BY item_key"), c.labelArgs(labels)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	keys := make([]string, 0)
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// PullConfig returns one key of the label set
+func (c *Client) PullConfig(key, contentType string, labels map[string]string) (interface{}, error) {
+	kv, err := c.read(c.labelArgs(labels), key)
+	if err != nil {
+		return nil, err
+	}
+	v, ok := kv[key]
+	if !ok {
+		return nil, config.ErrKeyNotExist
+	}
+	return v, nil
+}
+
+// PushConfigs writes items and bumps the revision of the label set in one transaction
+func (c *Client) PushConfigs(items map[string]interface{}, labels map[string]string) (map[string]interface{}, error) {
+	return c.PushConfigsIf(items, labels, config.Precondition{})
+}
+
+// PushConfigsIf writes items if revisions satisfy the precondition,
+// revisions are checked in the transaction after the revision row of label set is locked
+func (c *Client) PushConfigsIf(items map[string]interface{}, labels map[string]string, pre config.Precondition) (map[string]interface{}, error) {
+	if len(items) == 0 {
+		return nil, errors.New("data is empty, nothing to push")
+	}
+	return c.update(config.BatchOp{Labels: labels, Put: items, If: pre})
+}
+
+// DeleteConfigsByKeys deletes keys and bumps the revision of the label set in one transaction
+func (c *Client) DeleteConfigsByKeys(keys []string, labels map[string]string) (map[string]interface{}, error) {
+	return c.DeleteConfigsByKeysIf(keys, labels, config.Precondition{})
+}
+
+// DeleteConfigsByKeysIf deletes keys if revisions satisfy the precondition, like PushConfigsIf
+func (c *Client) DeleteConfigsByKeysIf(keys []string, labels map[string]string, pre config.Precondition) (map[string]interface{}, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no key need to delete, please check keys")
+	}
+	return c.update(config.BatchOp{Labels: labels, Delete: keys, If: pre})
+}
+
+// Batch applies operations in one transaction, all or nothing
+func (c *Client) Batch(ops []config.BatchOp) (*config.BatchResult, error) {
+	if len(ops) == 0 {
+		return nil, errors.New("batch is empty")
+	}
+	tx, err := c.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	result := &config.BatchResult{Atomic: true, Ops: make([]config.OpResult, len(ops))}
+	for i, op := range ops {
+		revision, err := c.apply(tx, op)
+		if err != nil {
+			c.rollback(tx)
+			return config.NewAtomicFailure(len(ops), i, err)
+		}
+		result.Ops[i] = config.OpResult{
+			Status:  config.OpApplied,
+			Results: []map[string]interface{}{{"revision": revision}},
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) update(op config.BatchOp) (map[string]interface{}, error) {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	revision, err := c.apply(tx, op)
+	if err != nil {
+		c.rollback(tx)
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"revision": revision}, nil
+}
+
+func (c *Client) rollback(tx *sql.Tx) {
+	if e := tx.Rollback(); e != nil {
+		openlogging.GetLogger().Errorf("rollback failed: %s", e)
+	}
+}
+
+// apply bumps the revision of label set, checks the precondition, then writes puts and deletes in a transaction
+func (c *Client) apply(tx *sql.Tx, op config.BatchOp) (int64, error) {
+	args := c.labelArgs(op.Labels)
+	revision, err := c.bump(tx, args)
+	if err != nil {
+		return 0, err
+	}
+	if op.If.Revision != "" || len(op.If.Keys) != 0 {
+		//bump locks the revision row, so revisions before this write are stable
+		current, err := c.revisions(tx, args, revision-1)
+		if err != nil {
+			return 0, err
+		}
+		if err := op.If.Check(current); err != nil {
+			return 0, err
+		}
+	}
+	now := time.Now().UTC()
+	for k, v := range op.Put {
+		value, contentType, err := encode(v)
+		if err != nil {
+			return 0, err
+		}
+		res, err := tx.Exec(c.rebind("UPDATE config_item SET item_value = ?, content_type = ?, revision = ?, updated_at = ? WHERE "+labelFilter+" AND item_key = ?"),
+			append([]interface{}{value, contentType, revision, now}, append(args, k)...)...)
+		if err != nil {
+			return 0, err
+		}
+		if n, err := res.RowsAffected(); err != nil || n != 0 {
+			continue
+		}
+		_, err = tx.Exec(c.rebind("INSERT INTO config_item (app, service, version, environment, item_key, item_value, content_type, revision, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+			append(args, k, value, contentType, revision, now)...)
+		if err != nil {
+			return 0, err
+		}
+	}
+	for _, k := range op.Delete {
+		if _, err := tx.Exec(c.rebind("DELETE FROM config_item WHERE "+labelFilter+" AND item_key = ?"), append(args, k)...); err != nil {
+			return 0, err
+		}
+	}
+	return revision, nil
+}
+
+// bump increases the revision of label set, it returns the new revision
+func (c *Client) bump(tx *sql.Tx, args []interface{}) (int64, error) {
+	now := time.Now().UTC()
+	res, err := tx.Exec(c.rebind("UPDATE config_revision SET revision = revision + 1, updated_at = ? WHERE "+labelFilter),
+		append([]interface{}{now}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return 0, err
+	} else if n == 0 {
+		_, err = tx.Exec(c.rebind("INSERT INTO config_revision (app, service, version, environment, revision, updated_at) VALUES (?, ?, ?, ?, 1, ?)"),
+			append(args, now)...)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return c.revision(tx, args)
+}
+
+// Watch polls the revision of the label set, it calls f with all keys after the revision changed
+func (c *Client) Watch(f func(map[string]interface{}), errHandler func(err error), labels map[string]string) error {
+	args := c.labelArgs(labels)
+	last, err := c.revision(c.db, args)
+	if err != nil {
+		return err
+	}
+	lastKV, err := c.read(args, "")
+	if err != nil {
+		return err
+	}
+	d := c.opts.WatchDuration
+	if d <= 0 {
+		d = defaultWatchDuration
+	}
+	go func() {
+		for range util.Tick(d, c.done) {
+			r, err := c.revision(c.db, args)
+			if err != nil {
+				errHandler(err)
+				continue
+			}
+			if r == last {
+				continue
+			}
+			kv, err := c.read(args, "")
+			if err != nil {
+				errHandler(err)
+				continue
+			}
+			last = r
+			if reflect.DeepEqual(kv, lastKV) {
+				continue
+			}
+			lastKV = kv
+			f(kv)
+		}
+	}()
+	return nil
+}
+
+// Close stops watches and closes the database
+func (c *Client) Close() error {
+	c.once.Do(func() {
+		close(c.done)
+	})
+	return c.db.Close()
+}
+
+// Options return settings
+func (c *Client) Options() config.Options {
+	return c.opts
+}
+
+func init() {
+	config.InstallConfigClientPlugin(Name, NewClient, config.CapabilityRead, config.CapabilityWrite, config.CapabilityWatch, config.CapabilityList)
+}
@@ -0,0 +1,147 @@
+package sql_test
+
+import (
+	"database/sql"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-chassis-config"
+	configsql "github.com/go-chassis/go-chassis-config/sql"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sql-plugin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	dsn := filepath.Join(dir, "config.db")
+	db, err := sql.Open("sqlite3", dsn)
+	assert.NoError(t, err)
+	_, err = db.Exec(configsql.Schema)
+	assert.NoError(t, err)
+	db.Close()
+
+	c, err := config.NewClient(configsql.Name, config.Options{
+		ServerURI:     dsn,
+		WatchDuration: 50 * time.Millisecond,
+		Labels:        map[string]string{config.LabelApp: "mall", config.LabelService: "cart"},
+		Params:        map[string]string{configsql.ParamDriver: "sqlite3"},
+	})
+	assert.NoError(t, err)
+	defer c.(io.Closer).Close()
+
+	events := make(chan map[string]interface{}, 10)
+	err = c.Watch(func(m map[string]interface{}) {
+		events <- m
+	}, func(err error) {
+		t.Log(err)
+	}, nil)
+	assert.NoError(t, err)
+
+	r, err := c.PushConfigs(map[string]interface{}{"timeout": "1s", "retry": 3}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), r["revision"])
+	r, err = c.PushConfigs(map[string]interface{}{"timeout": "2s"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), r["revision"])
+	select {
+	case m := <-events:
+		assert.Equal(t, "2s", m["timeout"])
+		assert.Equal(t, float64(3), m["retry"])
+	case <-time.After(3 * time.Second):
+		t.Fatal("no event received")
+	}
+
+	r, err = c.DeleteConfigsByKeys([]string{"timeout"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), r["revision"])
+	select {
+	case m := <-events:
+		assert.Nil(t, m["timeout"])
+	case <-time.After(3 * time.Second):
+		t.Fatal("no event received")
+	}
+	_, err = c.PullConfig("timeout", "", nil)
+	assert.Equal(t, config.ErrKeyNotExist, err)
+
+	//label columns filter configs
+	m, err := c.PullConfigs(map[string]string{config.LabelApp: "mall"})
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	rev, err := c.(*configsql.Client).Revision(map[string]string{config.LabelApp: "mall"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), rev)
+}
+
+func TestClient_PushConfigsIf(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sql-plugin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "config.db"))
+	assert.NoError(t, err)
+	_, err = db.Exec(configsql.Schema)
+	assert.NoError(t, err)
+	c := configsql.New(db, "sqlite3", config.Options{Labels: map[string]string{config.LabelApp: "mall"}})
+	defer c.Close()
+
+	_, err = config.PushConfigsIf(c, map[string]interface{}{"timeout": "1s"}, nil,
+		config.Precondition{Revision: "0", Keys: map[string]string{"timeout": ""}})
+	assert.NoError(t, err)
+	_, err = c.PushConfigs(map[string]interface{}{"retry": 3}, nil)
+	assert.NoError(t, err)
+	r, err := config.GetRevisions(c, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &config.Revisions{Revision: "2", Keys: map[string]string{"timeout": "1", "retry": "2"}}, r)
+
+	//revision of timeout is unchanged by the write of retry
+	_, err = config.PushConfigsIf(c, map[string]interface{}{"timeout": "2s"}, nil,
+		config.Precondition{Keys: map[string]string{"timeout": "1"}})
+	assert.NoError(t, err)
+	_, err = config.DeleteConfigsByKeysIf(c, []string{"retry"}, nil, config.Precondition{Revision: "2"})
+	conflict, ok := err.(*config.ConflictError)
+	assert.True(t, ok)
+	assert.Equal(t, "3", conflict.Current)
+	v, err := c.PullConfig("retry", "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(3), v)
+}
+
+func TestClient_Batch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sql-plugin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "config.db"))
+	assert.NoError(t, err)
+	_, err = db.Exec(configsql.Schema)
+	assert.NoError(t, err)
+	c := configsql.New(db, "sqlite3", config.Options{Labels: map[string]string{config.LabelApp: "mall"}})
+	defer c.Close()
+	cart := map[string]string{config.LabelApp: "mall", config.LabelService: "cart"}
+
+	r, err := config.Batch(c, []config.BatchOp{
+		{Put: map[string]interface{}{"timeout": "1s", "retry": 3}},
+		{Labels: cart, Put: map[string]interface{}{"timeout": "2s"}},
+	})
+	assert.NoError(t, err)
+	assert.True(t, r.Atomic)
+	assert.Equal(t, int64(1), r.Ops[1].Results[0]["revision"])
+
+	r, err = config.Batch(c, []config.BatchOp{
+		{Delete: []string{"retry"}},
+		{Labels: cart, Put: map[string]interface{}{"timeout": "3s"}, If: config.Precondition{Revision: "0"}},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, config.OpFailed, r.Ops[1].Status)
+	assert.IsType(t, &config.ConflictError{}, r.Ops[1].Err)
+	kv, err := c.PullConfigs()
+	assert.NoError(t, err)
+	assert.Equal(t, float64(3), kv["retry"])
+	kv, err = c.PullConfigs(cart)
+	assert.NoError(t, err)
+	assert.Equal(t, "2s", kv["timeout"])
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-mesh/openlogging"
+)
+
+// OverflowPolicy decides what a stream does when its buffer is full
+type OverflowPolicy string
+
+// overflow policies
+const (
+	//OverflowBlock blocks the watch until the consumer receives, it may stall reading of the plugin
+	OverflowBlock OverflowPolicy = "block"
+	//OverflowDropOldest drops the oldest buffered configs
+	OverflowDropOldest OverflowPolicy = "dropOldest"
+	//OverflowCoalesce drops all buffered configs and keeps the latest one,
+	//it is only safe when every item is a snapshot, see WatchStream
+	OverflowCoalesce OverflowPolicy = "coalesce"
+)
+
+const defaultStreamSize = 16
+
+// StreamOptions is the settings of WatchStream
+type StreamOptions struct {
+	// Labels of configs, default labels of client are used if it is empty
+	Labels map[string]string
+	// Size of buffer, default is 16
+	Size int
+	// Policy is the overflow policy, default is OverflowCoalesce if client is a Reader, or else OverflowBlock
+	Policy OverflowPolicy
+	// ErrHandler receives watch errors, they are logged if it is nil
+	ErrHandler func(err error)
+}
+
+// StreamStats is the metrics of a stream
+type StreamStats struct {
+	Received  uint64
+	Dropped   uint64
+	Coalesced uint64
+}
+
+// Stream delivers watched configs by a bounded channel, so a slow consumer does not run on the watch goroutine
+type Stream struct {
+	ch     chan map[string]interface{}
+	policy OverflowPolicy
+	done   chan struct{}
+	once   sync.Once
+	//pull signals the worker to pull a snapshot, changes before the worker pulls are coalesced
+	pull chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+
+	received  uint64
+	dropped   uint64
+	coalesced uint64
+}
+
+// WatchStream watches configs of client and sends them to the channel of returned stream.
+// if client is a Reader, configs are pulled again after every change, so each item is a snapshot
+// even if callback of the plugin only carries changed keys, like config_center.
+// the pull runs on a worker goroutine of the stream, the watch callback only signals it,
+// so the watch goroutine of the plugin is never blocked by pulling.
+// otherwise callbacks are sent as they are, they may not be snapshots, so default policy is OverflowBlock
+func WatchStream(c Watcher, opts StreamOptions) (*Stream, error) {
+	if opts.Size <= 0 {
+		opts.Size = defaultStreamSize
+	}
+	r, snapshot := AsReader(c)
+	if opts.Policy == "" {
+		opts.Policy = OverflowBlock
+		if snapshot {
+			opts.Policy = OverflowCoalesce
+		}
+	}
+	s := &Stream{
+		ch:     make(chan map[string]interface{}, opts.Size),
+		policy: opts.Policy,
+		done:   make(chan struct{}),
+	}
+	errHandler := opts.ErrHandler
+	if errHandler == nil {
+		errHandler = func(err error) {
+			openlogging.GetLogger().Errorf("watch stream failed: %s", err)
+		}
+	}
+	f := s.send
+	if snapshot {
+		s.pull = make(chan struct{}, 1)
+		go s.run(r, opts.Labels, errHandler)
+		f = func(map[string]interface{}) {
+			select {
+			case s.pull <- struct{}{}:
+			default:
+			}
+		}
+	}
+	if err := c.Watch(f, errHandler, opts.Labels); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// run pulls a snapshot after each signal and sends it, until the stream is closed
+func (s *Stream) run(r Reader, labels map[string]string, errHandler func(err error)) {
+	for {
+		select {
+		case <-s.pull:
+		case <-s.done:
+			return
+		}
+		kv, err := r.PullConfigs(labels)
+		if err != nil {
+			errHandler(err)
+			continue
+		}
+		s.send(kv)
+	}
+}
+
+// C returns the channel of configs, it is closed after the stream is closed
+func (s *Stream) C() <-chan map[string]interface{} {
+	return s.ch
+}
+
+func (s *Stream) send(kv map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	atomic.AddUint64(&s.received, 1)
+	if s.policy == OverflowBlock {
+		select {
+		case s.ch <- kv:
+		case <-s.done:
+		}
+		return
+	}
+	for {
+		select {
+		case s.ch <- kv:
+			return
+		default:
+		}
+		if s.policy == OverflowDropOldest {
+			select {
+			case <-s.ch:
+				atomic.AddUint64(&s.dropped, 1)
+			default:
+			}
+			continue
+		}
+		for drained := false; !drained; {
+			select {
+			case <-s.ch:
+				atomic.AddUint64(&s.coalesced, 1)
+			default:
+				drained = true
+			}
+		}
+	}
+}
+
+// Stats returns metrics of the stream
+func (s *Stream) Stats() StreamStats {
+	return StreamStats{
+		Received:  atomic.LoadUint64(&s.received),
+		Dropped:   atomic.LoadUint64(&s.dropped),
+		Coalesced: atomic.LoadUint64(&s.coalesced),
+	}
+}
+
+// Close stops the stream and closes its channel, the watch of client lasts until the client is closed
+func (s *Stream) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.closed = true
+		close(s.ch)
+	})
+	return nil
+}
@@ -0,0 +1,113 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/stretchr/testify/assert"
+)
+
+// watchOnly is a watcher which can not pull configs
+type watchOnly struct {
+	f func(map[string]interface{})
+}
+
+func (w *watchOnly) Watch(f func(map[string]interface{}), errHandler func(err error), labels map[string]string) error {
+	w.f = f
+	return nil
+}
+
+// slowClient blocks pulling until gate is closed
+type slowClient struct {
+	watchClient
+	gate chan struct{}
+}
+
+func (c *slowClient) PullConfigs(labels ...map[string]string) (map[string]interface{}, error) {
+	<-c.gate
+	return c.watchClient.PullConfigs(labels...)
+}
+
+func TestWatchStream(t *testing.T) {
+	for _, tc := range []struct {
+		policy   config.OverflowPolicy
+		expected []string
+		stats    config.StreamStats
+	}{
+		{config.OverflowDropOldest, []string{"2", "3"}, config.StreamStats{Received: 3, Dropped: 1}},
+		{config.OverflowCoalesce, []string{"3"}, config.StreamStats{Received: 3, Coalesced: 2}},
+	} {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			c := &watchOnly{}
+			s, err := config.WatchStream(c, config.StreamOptions{Size: 2, Policy: tc.policy})
+			assert.NoError(t, err)
+			for _, v := range []string{"1", "2", "3"} {
+				c.f(map[string]interface{}{"a": v})
+			}
+			assert.NoError(t, s.Close())
+			received := make([]string, 0)
+			for kv := range s.C() {
+				received = append(received, kv["a"].(string))
+			}
+			assert.Equal(t, tc.expected, received)
+			assert.Equal(t, tc.stats, s.Stats())
+			c.f(map[string]interface{}{"a": "4"})
+		})
+	}
+	t.Run("coalesced items are snapshots", func(t *testing.T) {
+		c := &watchClient{mapReader: mapReader{kv: map[string]interface{}{"a": "1", "b": "1"}}}
+		s, err := config.WatchStream(c, config.StreamOptions{Size: 1})
+		assert.NoError(t, err)
+		c.change(map[string]interface{}{"a": "2", "b": "1"}, map[string]interface{}{"a": "2"})
+		//deletion only carries the deleted key
+		c.change(map[string]interface{}{"a": "2"}, map[string]interface{}{"b": "1"})
+		for kv := range s.C() {
+			if len(kv) == 1 {
+				assert.Equal(t, map[string]interface{}{"a": "2"}, kv)
+				break
+			}
+			assert.Equal(t, map[string]interface{}{"a": "2", "b": "1"}, kv)
+		}
+		assert.NoError(t, s.Close())
+	})
+	t.Run("pulling does not block watch", func(t *testing.T) {
+		c := &slowClient{watchClient: watchClient{mapReader: mapReader{kv: map[string]interface{}{"a": "1"}}}, gate: make(chan struct{})}
+		s, err := config.WatchStream(c, config.StreamOptions{Size: 1})
+		assert.NoError(t, err)
+		changed := make(chan struct{})
+		go func() {
+			for _, v := range []string{"2", "3", "4"} {
+				c.change(map[string]interface{}{"a": v}, map[string]interface{}{"a": v})
+			}
+			close(changed)
+		}()
+		select {
+		case <-changed:
+		case <-time.After(time.Second):
+			t.Fatal("watch callback is blocked by pulling")
+		}
+		close(c.gate)
+		for kv := range s.C() {
+			if kv["a"] == "4" {
+				break
+			}
+		}
+		assert.NoError(t, s.Close())
+	})
+	t.Run("block is default of watchers which are not readers", func(t *testing.T) {
+		c := &watchOnly{}
+		s, err := config.WatchStream(c, config.StreamOptions{Size: 1})
+		assert.NoError(t, err)
+		c.f(map[string]interface{}{"a": "1"})
+		sent := make(chan struct{})
+		go func() {
+			c.f(map[string]interface{}{"a": "2"})
+			close(sent)
+		}()
+		assert.Equal(t, "1", (<-s.C())["a"])
+		<-sent
+		assert.Equal(t, "2", (<-s.C())["a"])
+		assert.NoError(t, s.Close())
+	})
+}
@@ -0,0 +1,518 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tags of struct fields
+const (
+	//TagKey is the tag of config key, like `config:"max_conn"`, "-" skips the field.
+	//yaml tag is used if it is absent, field name is used if both are absent, keys are matched case insensitively
+	TagKey = "config"
+	//TagDefault is the tag of default value, it is used if the key is absent, like `default:"10s"`
+	TagDefault = "default"
+)
+
+// ErrInvalidTarget means target of Unmarshal is not a non nil pointer
+var ErrInvalidTarget = errors.New("target must be a non nil pointer")
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// UnmarshalError holds all failures of binding
+type UnmarshalError struct {
+	Errors []error
+}
+
+// Error joins all failures
+func (e *UnmarshalError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d error(s) decoding configs: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Unmarshal pulls configs of labels and binds keys under prefix to target, target must be a pointer,
+// for example keys db.host and db.timeout are bound to struct {Host string; Timeout time.Duration} with prefix db.
+// it converts strings to numbers, bools and durations, fields of absent keys are set by default tag or left unchanged.
+// all conversion failures are returned together as *UnmarshalError
+func Unmarshal(c Reader, prefix string, target interface{}, labels ...map[string]string) error {
+	kv, err := c.PullConfigs(labels...)
+	if err != nil {
+		return err
+	}
+	return UnmarshalMap(kv, prefix, target)
+}
+
+// UnmarshalMap binds flat configs under prefix to target, like Unmarshal
+func UnmarshalMap(kv map[string]interface{}, prefix string, target interface{}) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return ErrInvalidTarget
+	}
+	d := &decoder{}
+	v, ok := tree(kv, prefix)
+	if ok {
+		d.decode(prefix, v, rv.Elem())
+	} else {
+		d.defaults(prefix, rv.Elem())
+	}
+	if len(d.errs) != 0 {
+		return &UnmarshalError{Errors: d.errs}
+	}
+	return nil
+}
+
+// tree converts flat keys under prefix to a nested map, a key equal to prefix returns its value
+func tree(kv map[string]interface{}, prefix string) (interface{}, bool) {
+	if v, ok := kv[prefix]; ok && prefix != "" {
+		return v, true
+	}
+	root := make(map[string]interface{})
+	found := false
+	for k, v := range kv {
+		if prefix != "" {
+			if !strings.HasPrefix(k, prefix+".") {
+				continue
+			}
+			k = k[len(prefix)+1:]
+		}
+		found = true
+		parts := strings.Split(k, ".")
+		m := root
+		for _, p := range parts[:len(parts)-1] {
+			sub, ok := m[p].(map[string]interface{})
+			if !ok {
+				sub = make(map[string]interface{})
+				if old, exist := m[p]; exist {
+					//a value and nested keys share the name, the value is kept under empty key
+					sub[""] = old
+				}
+				m[p] = sub
+			}
+			m = sub
+		}
+		last := parts[len(parts)-1]
+		if sub, ok := m[last].(map[string]interface{}); ok {
+			sub[""] = v
+		} else {
+			m[last] = v
+		}
+	}
+	return root, found
+}
+
+type decoder struct {
+	errs []error
+}
+
+func (d *decoder) fail(path string, format string, args ...interface{}) {
+	d.errs = append(d.errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+// defaults sets default tags of struct fields without keys
+func (d *decoder) defaults(path string, rv reflect.Value) {
+	if rv.Kind() == reflect.Struct && rv.Type() != durationType {
+		d.decodeStruct(path, map[string]interface{}{}, rv)
+	}
+}
+
+func (d *decoder) decode(path string, v interface{}, rv reflect.Value) {
+	if v == nil {
+		return
+	}
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		d.decode(path, v, rv.Elem())
+		return
+	}
+	if s, ok := v.(string); ok && rv.CanAddr() && rv.Addr().Type().Implements(textUnmarshalerType) {
+		if err := rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			d.fail(path, "%s", err)
+		}
+		return
+	}
+	if rv.Type() == durationType {
+		d.decodeDuration(path, v, rv)
+		return
+	}
+	switch rv.Kind() {
+	case reflect.Interface:
+		if !reflect.TypeOf(v).AssignableTo(rv.Type()) {
+			d.fail(path, "can not assign %T to %s", v, rv.Type())
+			return
+		}
+		rv.Set(reflect.ValueOf(v))
+	case reflect.String:
+		switch value := v.(type) {
+		case string:
+			rv.SetString(value)
+		case []byte:
+			rv.SetString(string(value))
+		case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			rv.SetString(fmt.Sprint(value))
+		default:
+			d.fail(path, "can not convert %T to string", v)
+		}
+	case reflect.Bool:
+		d.decodeBool(path, v, rv)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := d.integer(path, v, rv.Type())
+		if !ok {
+			return
+		}
+		if rv.OverflowInt(i) {
+			d.fail(path, "%v overflows %s", v, rv.Type())
+			return
+		}
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, ok := d.unsigned(path, v, rv.Type())
+		if !ok {
+			return
+		}
+		if rv.OverflowUint(u) {
+			d.fail(path, "%v overflows %s", v, rv.Type())
+			return
+		}
+		rv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, ok := d.number(path, v)
+		if !ok {
+			return
+		}
+		if rv.OverflowFloat(f) {
+			d.fail(path, "%v overflows %s", v, rv.Type())
+			return
+		}
+		rv.SetFloat(f)
+	case reflect.Slice:
+		d.decodeSlice(path, v, rv)
+	case reflect.Map:
+		d.decodeMap(path, v, rv)
+	case reflect.Struct:
+		m, ok := toMap(v)
+		if !ok {
+			d.fail(path, "can not convert %T to %s", v, rv.Type())
+			return
+		}
+		d.decodeStruct(path, m, rv)
+	default:
+		d.fail(path, "unsupported type %s", rv.Type())
+	}
+}
+
+func (d *decoder) decodeDuration(path string, v interface{}, rv reflect.Value) {
+	if s, ok := v.(string); ok {
+		t, err := time.ParseDuration(strings.TrimSpace(s))
+		if err != nil {
+			d.fail(path, "%s", err)
+			return
+		}
+		rv.SetInt(int64(t))
+		return
+	}
+	f, ok := d.number(path, v)
+	if !ok {
+		return
+	}
+	rv.SetInt(int64(f))
+}
+
+func (d *decoder) decodeBool(path string, v interface{}, rv reflect.Value) {
+	switch value := v.(type) {
+	case bool:
+		rv.SetBool(value)
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			d.fail(path, "can not convert %q to bool", value)
+			return
+		}
+		rv.SetBool(b)
+	default:
+		f, ok := d.number(path, v)
+		if ok {
+			rv.SetBool(f != 0)
+		}
+	}
+}
+
+// number converts numbers and numeric strings to float64
+func (d *decoder) number(path string, v interface{}) (float64, bool) {
+	if s, ok := v.(string); ok {
+		s = strings.TrimSpace(s)
+		if i, err := strconv.ParseInt(s, 0, 64); err == nil {
+			return float64(i), true
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			d.fail(path, "can not convert %q to number", s)
+			return 0, false
+		}
+		return f, true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	d.fail(path, "can not convert %T to number", v)
+	return 0, false
+}
+
+// integer converts integers and integer strings to int64 directly, so big integers do not lose precision,
+// other numbers are accepted if they are integral
+func (d *decoder) integer(path string, v interface{}, t reflect.Type) (int64, bool) {
+	if s, ok := v.(string); ok {
+		if i, err := strconv.ParseInt(strings.TrimSpace(s), 0, 64); err == nil {
+			return i, true
+		}
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() <= math.MaxInt64 {
+			return int64(rv.Uint()), true
+		}
+		d.fail(path, "%v overflows %s", v, t)
+		return 0, false
+	}
+	f, ok := d.number(path, v)
+	if !ok {
+		return 0, false
+	}
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		d.fail(path, "%v overflows %s", v, t)
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// unsigned is integer of unsigned types
+func (d *decoder) unsigned(path string, v interface{}, t reflect.Type) (uint64, bool) {
+	if s, ok := v.(string); ok {
+		if u, err := strconv.ParseUint(strings.TrimSpace(s), 0, 64); err == nil {
+			return u, true
+		}
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if rv.Int() >= 0 {
+			return uint64(rv.Int()), true
+		}
+		d.fail(path, "%v overflows %s", v, t)
+		return 0, false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), true
+	}
+	f, ok := d.number(path, v)
+	if !ok {
+		return 0, false
+	}
+	if f < 0 || f != math.Trunc(f) || f >= math.MaxUint64 {
+		d.fail(path, "%v overflows %s", v, t)
+		return 0, false
+	}
+	return uint64(f), true
+}
+
+func toMap(v interface{}) (map[string]interface{}, bool) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		return value, true
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, sub := range value {
+			m[fmt.Sprint(k)] = sub
+		}
+		return m, true
+	}
+	return nil, false
+}
+
+// decodeSlice accepts a list, a comma separated string, or keys of indexes like servers.0 and servers.1
+func (d *decoder) decodeSlice(path string, v interface{}, rv reflect.Value) {
+	var items []interface{}
+	switch value := v.(type) {
+	case []interface{}:
+		items = value
+	case string:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			rv.SetBytes([]byte(value))
+			return
+		}
+		items = make([]interface{}, 0)
+		for _, s := range strings.Split(value, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				items = append(items, s)
+			}
+		}
+	default:
+		m, ok := toMap(v)
+		if !ok {
+			items = []interface{}{v}
+			break
+		}
+		indexes := make([]int, 0, len(m))
+		for k := range m {
+			i, err := strconv.Atoi(k)
+			if err != nil || i < 0 {
+				d.fail(path, "%q is not an index of list", k)
+				return
+			}
+			indexes = append(indexes, i)
+		}
+		sort.Ints(indexes)
+		for _, i := range indexes {
+			items = append(items, m[strconv.Itoa(i)])
+		}
+	}
+	s := reflect.MakeSlice(rv.Type(), len(items), len(items))
+	for i, item := range items {
+		d.decode(fmt.Sprintf("%s[%d]", path, i), item, s.Index(i))
+	}
+	rv.Set(s)
+}
+
+func (d *decoder) decodeMap(path string, v interface{}, rv reflect.Value) {
+	m, ok := toMap(v)
+	if !ok {
+		d.fail(path, "can not convert %T to %s", v, rv.Type())
+		return
+	}
+	t := rv.Type()
+	elemKind := t.Elem().Kind()
+	if elemKind != reflect.Struct && elemKind != reflect.Map && elemKind != reflect.Ptr && elemKind != reflect.Interface {
+		//keys of scalar maps may contain dots, like labels.app.name
+		m = flatMap(m)
+	}
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMapWithSize(t, len(m)))
+	}
+	for k, sub := range m {
+		key := reflect.New(t.Key()).Elem()
+		d.decode(join(path, k), k, key)
+		elem := reflect.New(t.Elem()).Elem()
+		d.decode(join(path, k), sub, elem)
+		rv.SetMapIndex(key, elem)
+	}
+}
+
+func flatMap(m map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if sub, ok := toMap(v); ok {
+			for sk, sv := range flatMap(sub) {
+				if sk == "" {
+					result[k] = sv
+				} else {
+					result[k+"."+sk] = sv
+				}
+			}
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// lookup finds key in m, exact match first, then case insensitive match, it returns the matched key
+func lookup(m map[string]interface{}, key string) (string, interface{}, bool) {
+	if v, ok := m[key]; ok {
+		return key, v, true
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return k, v, true
+		}
+	}
+	return "", nil, false
+}
+
+func (d *decoder) decodeStruct(path string, m map[string]interface{}, rv reflect.Value) {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Tag.Get(TagKey)
+		if name == "" {
+			name = strings.Split(field.Tag.Get("yaml"), ",")[0]
+		}
+		if name == "-" {
+			continue
+		}
+		fv := rv.Field(i)
+		if name == "" && field.Anonymous && indirect(field.Type).Kind() == reflect.Struct {
+			//embedded struct shares keys with its parent
+			if fv.Kind() == reflect.Ptr && fv.IsNil() {
+				fv.Set(reflect.New(field.Type.Elem()))
+			}
+			d.decodeStruct(path, m, reflect.Indirect(fv))
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if k, v, ok := lookup(m, name); ok {
+			d.decode(join(path, k), v, fv)
+			continue
+		}
+		key := join(path, name)
+		if def, ok := field.Tag.Lookup(TagDefault); ok {
+			d.decode(key, def, fv)
+			continue
+		}
+		switch {
+		case fv.Kind() == reflect.Struct:
+			d.defaults(key, fv)
+		case fv.Kind() == reflect.Ptr && fv.Type().Elem().Kind() == reflect.Struct:
+			if !fv.IsNil() {
+				d.defaults(key, fv.Elem())
+				continue
+			}
+			//the pointer is set only if the struct has defaults
+			p := reflect.New(fv.Type().Elem())
+			d.defaults(key, p.Elem())
+			if !p.Elem().IsZero() {
+				fv.Set(p)
+			}
+		}
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
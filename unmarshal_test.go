@@ -0,0 +1,153 @@
+package config_test
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/stretchr/testify/assert"
+)
+
+type mapReader struct {
+	config.Client
+	kv map[string]interface{}
+}
+
+func (r *mapReader) PullConfigs(labels ...map[string]string) (map[string]interface{}, error) {
+	return r.kv, nil
+}
+
+type Pool struct {
+	MaxIdle int `config:"max_idle" default:"2"`
+}
+
+type DB struct {
+	Pool
+	Host     string
+	Port     int `yaml:"port"`
+	Timeout  time.Duration
+	Debug    bool
+	Ratio    float64  `default:"0.5"`
+	Tags     []string `config:"tags"`
+	Replicas []string `config:"replicas"`
+	Labels   map[string]string
+	IP       net.IP
+	Retry    *struct{ Times uint8 }
+	Ignored  string `config:"-"`
+	Servers  []struct{ Name string }
+}
+
+func TestUnmarshal(t *testing.T) {
+	r := &mapReader{kv: map[string]interface{}{
+		"db.host":             "127.0.0.1",
+		"db.port":             "3306",
+		"db.timeout":          "3s",
+		"db.debug":            "true",
+		"db.tags":             "a, b",
+		"db.replicas.1":       "r1",
+		"db.replicas.0":       "r0",
+		"db.labels.app":       "mall",
+		"db.labels.app.owner": "tom",
+		"db.ip":               "10.0.0.1",
+		"db.retry.times":      3.0,
+		"db.ignored":          "x",
+		"db.servers":          []interface{}{map[interface{}]interface{}{"name": "s0"}},
+		"other":               "o",
+	}}
+	db := &DB{}
+	assert.NoError(t, config.Unmarshal(r, "db", db))
+	assert.Equal(t, "127.0.0.1", db.Host)
+	assert.Equal(t, 3306, db.Port)
+	assert.Equal(t, 3*time.Second, db.Timeout)
+	assert.True(t, db.Debug)
+	assert.Equal(t, 0.5, db.Ratio)
+	assert.Equal(t, 2, db.MaxIdle)
+	assert.Equal(t, []string{"a", "b"}, db.Tags)
+	assert.Equal(t, []string{"r0", "r1"}, db.Replicas)
+	assert.Equal(t, map[string]string{"app": "mall", "app.owner": "tom"}, db.Labels)
+	assert.Equal(t, "10.0.0.1", db.IP.String())
+	assert.Equal(t, uint8(3), db.Retry.Times)
+	assert.Empty(t, db.Ignored)
+	assert.Equal(t, "s0", db.Servers[0].Name)
+
+	t.Run("errors are reported together", func(t *testing.T) {
+		err := config.UnmarshalMap(map[string]interface{}{
+			"port":        "abc",
+			"timeout":     "3 apples",
+			"retry.times": 300,
+		}, "", &DB{})
+		e, ok := err.(*config.UnmarshalError)
+		assert.True(t, ok)
+		assert.Equal(t, 3, len(e.Errors))
+		assert.Contains(t, err.Error(), "retry.times")
+	})
+	t.Run("target must be a pointer", func(t *testing.T) {
+		assert.Equal(t, config.ErrInvalidTarget, config.UnmarshalMap(nil, "", DB{}))
+	})
+}
+
+func TestUnmarshalMap_Integers(t *testing.T) {
+	var v struct {
+		ID     int64
+		Seq    uint64
+		Offset int64
+		Size   uint64
+		Small  int8
+		Count  uint
+	}
+	err := config.UnmarshalMap(map[string]interface{}{
+		"id":     "9007199254740993",
+		"seq":    "18446744073709551615",
+		"offset": int64(-9007199254740993),
+		"size":   uint64(18446744073709551615),
+		"small":  float64(100),
+		"count":  "0x10",
+	}, "", &v)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9007199254740993), v.ID)
+	assert.Equal(t, uint64(18446744073709551615), v.Seq)
+	assert.Equal(t, int64(-9007199254740993), v.Offset)
+	assert.Equal(t, uint64(18446744073709551615), v.Size)
+	assert.Equal(t, int8(100), v.Small)
+	assert.Equal(t, uint(16), v.Count)
+
+	err = config.UnmarshalMap(map[string]interface{}{
+		"id":    uint64(18446744073709551615),
+		"seq":   "-1",
+		"small": "1000",
+		"count": 1.5,
+	}, "", &v)
+	assert.Len(t, err.(*config.UnmarshalError).Errors, 4)
+}
+
+func TestUnmarshalMap_PointerDefaults(t *testing.T) {
+	var v struct {
+		Pool  *Pool
+		Retry *struct{ Times int }
+	}
+	assert.NoError(t, config.UnmarshalMap(map[string]interface{}{}, "", &v))
+	assert.Equal(t, &Pool{MaxIdle: 2}, v.Pool)
+	assert.Nil(t, v.Retry)
+}
+
+func TestUnmarshalMap_Interfaces(t *testing.T) {
+	var v struct {
+		Any    interface{}
+		Err    error
+		Name   fmt.Stringer
+		Values []interface{}
+	}
+	err := config.UnmarshalMap(map[string]interface{}{
+		"Any":    "a",
+		"Err":    "failed",
+		"Name":   1,
+		"Values": []interface{}{1, "b"},
+	}, "", &v)
+	assert.Len(t, err.(*config.UnmarshalError).Errors, 2)
+	assert.Equal(t, "a", v.Any)
+	assert.Nil(t, v.Err)
+	assert.Nil(t, v.Name)
+	assert.Equal(t, []interface{}{1, "b"}, v.Values)
+}
@@ -0,0 +1,72 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vault
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// auth methods
+const (
+	AuthToken      = "token"
+	AuthAppRole    = "approle"
+	AuthKubernetes = "kubernetes"
+)
+
+const defaultJWTPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// login returns a vault token according to auth method in params
+func (c *Client) login() (string, error) {
+	method := c.params(ParamAuth, AuthToken)
+	var body map[string]interface{}
+	switch method {
+	case AuthToken:
+		token := c.params(ParamToken, "")
+		if token == "" {
+			return "", ErrTokenEmpty
+		}
+		return token, nil
+	case AuthAppRole:
+		body = map[string]interface{}{
+			"role_id":   c.params(ParamRoleID, ""),
+			"secret_id": c.params(ParamSecretID, ""),
+		}
+	case AuthKubernetes:
+		jwt, err := ioutil.ReadFile(c.params(ParamJWTPath, defaultJWTPath))
+		if err != nil {
+			return "", err
+		}
+		body = map[string]interface{}{
+			"role": c.params(ParamRole, ""),
+			"jwt":  strings.TrimSpace(string(jwt)),
+		}
+	default:
+		return "", fmt.Errorf("unknown vault auth method [%s]", method)
+	}
+	auth := &Auth{}
+	api := "/v1/auth/" + c.params(ParamAuthMount, method) + "/login"
+	if _, err := c.do("POST", api, "", body, auth); err != nil {
+		return "", err
+	}
+	if auth.Auth.ClientToken == "" {
+		return "", fmt.Errorf("%s login returns empty token", method)
+	}
+	return auth.Auth.ClientToken, nil
+}
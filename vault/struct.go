@@ -0,0 +1,62 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vault
+
+// Secret is the response of kv v2 read api
+type Secret struct {
+	Data struct {
+		Data     map[string]interface{} `json:"data"`
+		Metadata VersionMetadata        `json:"metadata"`
+	} `json:"data"`
+}
+
+// VersionMetadata describes one version of a secret
+type VersionMetadata struct {
+	Version      int    `json:"version"`
+	CreatedTime  string `json:"created_time"`
+	DeletionTime string `json:"deletion_time"`
+	Destroyed    bool   `json:"destroyed"`
+}
+
+// Metadata is the response of kv v2 metadata api
+type Metadata struct {
+	Data struct {
+		CurrentVersion int                        `json:"current_version"`
+		Versions       map[string]VersionMetadata `json:"versions"`
+	} `json:"data"`
+}
+
+// WriteRequest is the request body of kv v2 write api
+type WriteRequest struct {
+	Options map[string]interface{} `json:"options,omitempty"`
+	Data    map[string]interface{} `json:"data"`
+}
+
+// WriteResponse is the response of kv v2 write api
+type WriteResponse struct {
+	Data VersionMetadata `json:"data"`
+}
+
+// Auth is the response of login api
+type Auth struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+}
@@ -0,0 +1,540 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package vault is the config client plugin of hashicorp vault kv secrets engine version 2
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chassis/foundation/httpclient"
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/go-chassis/go-chassis-config/pkg/util"
+	"github.com/go-chassis/go-chassis-config/serializers"
+	"github.com/go-mesh/openlogging"
+)
+
+const (
+	// Name of the Plugin
+	Name = "vault"
+	//LabelPath is the label of secret path, if it is absent,
+	//path is joined by app, environment, serviceName and version labels
+	LabelPath = "path"
+
+	//ParamMount is the mount path of kv engine, default is secret
+	ParamMount = "mount"
+	//ParamAuth is the auth method, token, approle or kubernetes, default is token
+	ParamAuth = "auth"
+	//ParamAuthMount is the mount path of auth method, default is the name of method
+	ParamAuthMount = "authMount"
+	//ParamToken is the token of token auth method
+	ParamToken = "token"
+	//ParamRoleID is the role id of approle auth method
+	ParamRoleID = "roleID"
+	//ParamSecretID is the secret id of approle auth method
+	ParamSecretID = "secretID"
+	//ParamRole is the role of kubernetes auth method
+	ParamRole = "role"
+	//ParamJWTPath is the service account token file of kubernetes auth method
+	ParamJWTPath = "jwtPath"
+
+	headerToken          = "X-Vault-Token"
+	defaultMount         = "secret"
+	defaultWatchDuration = 30 * time.Second
+)
+
+// errors
+var (
+	ErrInvalidEP  = errors.New("invalid endpoint")
+	ErrPathEmpty  = errors.New("secret path can not be empty")
+	ErrTokenEmpty = errors.New("vault token can not be empty")
+)
+
+// Client is vault kv v2 config client implementation
+type Client struct {
+	opts config.Options
+	c    *httpclient.Requests
+	addr string
+
+	mu    sync.RWMutex
+	token string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewClient create vault config client
+func NewClient(options config.Options) (config.Client, error) {
+	addr := strings.TrimSuffix(strings.TrimSpace(options.ServerURI), "/")
+	if addr == "" {
+		return nil, ErrInvalidEP
+	}
+	hc, err := httpclient.New(&httpclient.Options{
+		SSLEnabled: options.EnableSSL,
+		TLSConfig:  options.TLSConfig,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		opts: options,
+		c:    hc,
+		addr: addr,
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	openlogging.Info("new vault client", openlogging.WithTags(
+		openlogging.Tags{
+			"ep":   addr,
+			"auth": c.params(ParamAuth, AuthToken),
+		}))
+	return c, nil
+}
+
+func (c *Client) params(key, defaultValue string) string {
+	if v := c.opts.Params[key]; v != "" {
+		return v
+	}
+	return defaultValue
+}
+
+// Path returns the secret path of labels
+func (c *Client) Path(labels map[string]string) (string, error) {
+	if len(labels) == 0 {
+		labels = c.opts.Labels
+	}
+	if p := strings.Trim(labels[LabelPath], "/"); p != "" {
+		return p, nil
+	}
+	elements := make([]string, 0, 4)
+	for _, l := range []string{config.LabelApp, config.LabelEnvironment, config.LabelService, config.LabelVersion} {
+		if labels[l] != "" {
+			elements = append(elements, labels[l])
+		}
+	}
+	if len(elements) == 0 {
+		return "", ErrPathEmpty
+	}
+	return strings.Join(elements, "/"), nil
+}
+
+// do sends a request to vault and decodes response to result, it returns the status code
+func (c *Client) do(method, api, token string, body interface{}, result interface{}) (int, error) {
+	var b []byte
+	var err error
+	if body != nil {
+		b, err = serializers.Encode(serializers.JsonEncoder, body)
+		if err != nil {
+			return 0, err
+		}
+	}
+	headers := make(http.Header)
+	if token != "" {
+		headers.Set(headerToken, token)
+	}
+	resp, err := c.c.Do(c.ctx, method, c.addr+api, headers, b)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	b, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+		return resp.StatusCode, fmt.Errorf("call %s failed, statusCode: %d, resp body: %s", api, resp.StatusCode, b)
+	}
+	if result != nil && len(b) != 0 {
+		return resp.StatusCode, serializers.Decode(serializers.JsonEncoder, b, result)
+	}
+	return resp.StatusCode, nil
+}
+
+// call sends an authenticated request, it logs in again if token is rejected
+func (c *Client) call(method, api string, body interface{}, result interface{}) (int, error) {
+	c.mu.RLock()
+	token := c.token
+	c.mu.RUnlock()
+	var err error
+	if token == "" {
+		if token, err = c.relogin(); err != nil {
+			return 0, err
+		}
+	}
+	status, err := c.do(method, api, token, body, result)
+	if status == http.StatusForbidden && c.params(ParamAuth, AuthToken) != AuthToken {
+		if token, err = c.relogin(); err != nil {
+			return 0, err
+		}
+		return c.do(method, api, token, body, result)
+	}
+	return status, err
+}
+
+func (c *Client) relogin() (string, error) {
+	token, err := c.login()
+	if err != nil {
+		openlogging.GetLogger().Errorf("vault login failed: %s", err)
+		return "", err
+	}
+	c.mu.Lock()
+	c.token = token
+	c.mu.Unlock()
+	return token, nil
+}
+
+// Read returns data and version of the latest version of a secret,
+// version is 0 if secret does not exist
+func (c *Client) Read(path string) (map[string]interface{}, int, error) {
+	s := &Secret{}
+	status, err := c.call(http.MethodGet, "/v1/"+c.params(ParamMount, defaultMount)+"/data/"+path, nil, s)
+	if status == http.StatusNotFound {
+		//the latest version may be deleted, metadata still holds its number
+		v, err := c.CurrentVersion(path)
+		return make(map[string]interface{}), v, err
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if s.Data.Data == nil {
+		s.Data.Data = make(map[string]interface{})
+	}
+	return s.Data.Data, s.Data.Metadata.Version, nil
+}
+
+// Write writes a new version of a secret, the write fails if cas is not the current version
+func (c *Client) Write(path string, data map[string]interface{}, cas int) (*VersionMetadata, error) {
+	req := &WriteRequest{
+		Options: map[string]interface{}{"cas": cas},
+		Data:    data,
+	}
+	resp := &WriteResponse{}
+	if _, err := c.call(http.MethodPost, "/v1/"+c.params(ParamMount, defaultMount)+"/data/"+path, req, resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// CurrentVersion returns the current version of a secret
+func (c *Client) CurrentVersion(path string) (int, error) {
+	m, err := c.Metadata(path)
+	if err != nil {
+		return 0, err
+	}
+	return m.Data.CurrentVersion, nil
+}
+
+// Metadata returns the current version and metadata of all versions of a secret
+func (c *Client) Metadata(path string) (*Metadata, error) {
+	m := &Metadata{}
+	status, err := c.call(http.MethodGet, "/v1/"+c.params(ParamMount, defaultMount)+"/metadata/"+path, nil, m)
+	if status == http.StatusNotFound {
+		return &Metadata{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ReadVersion returns data and metadata of a version of a secret,
+// it returns config.ErrRevisionNotExist if the version does not exist, or is deleted or destroyed
+func (c *Client) ReadVersion(path string, version int) (map[string]interface{}, *VersionMetadata, error) {
+	s := &Secret{}
+	api := "/v1/" + c.params(ParamMount, defaultMount) + "/data/" + path + "?version=" + strconv.Itoa(version)
+	status, err := c.call(http.MethodGet, api, nil, s)
+	if status == http.StatusNotFound {
+		return nil, nil, config.ErrRevisionNotExist
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.Data.Data, &s.Data.Metadata, nil
+}
+
+func newRevision(key string, version int, data map[string]interface{}, m *VersionMetadata) config.Revision {
+	r := config.Revision{
+		Key:      key,
+		Revision: strconv.Itoa(version),
+		Value:    data[key],
+	}
+	if t, err := time.Parse(time.RFC3339Nano, m.CreatedTime); err == nil {
+		r.Timestamp = t
+	}
+	return r
+}
+
+// History returns versions of the secret which changed the key, latest first,
+// deleted and destroyed versions are skipped. vault does not record authors
+func (c *Client) History(key string, labels map[string]string) ([]config.Revision, error) {
+	path, err := c.Path(labels)
+	if err != nil {
+		return nil, err
+	}
+	m, err := c.Metadata(path)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]int, 0, len(m.Data.Versions))
+	for v, vm := range m.Data.Versions {
+		n, err := strconv.Atoi(v)
+		if err != nil || vm.Destroyed || vm.DeletionTime != "" {
+			continue
+		}
+		versions = append(versions, n)
+	}
+	sort.Ints(versions)
+	result := make([]config.Revision, 0)
+	for _, v := range versions {
+		data, vm, err := c.ReadVersion(path, v)
+		if err == config.ErrRevisionNotExist {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		r := newRevision(key, v, data, vm)
+		if len(result) == 0 && r.Value == nil {
+			//key is not created yet
+			continue
+		}
+		if len(result) != 0 && reflect.DeepEqual(result[len(result)-1].Value, r.Value) {
+			continue
+		}
+		result = append(result, r)
+	}
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result, nil
+}
+
+// GetRevision returns the value of key in a version of the secret
+func (c *Client) GetRevision(key, revision string, labels map[string]string) (*config.Revision, error) {
+	path, err := c.Path(labels)
+	if err != nil {
+		return nil, err
+	}
+	v, err := strconv.Atoi(revision)
+	if err != nil {
+		return nil, config.ErrRevisionNotExist
+	}
+	data, vm, err := c.ReadVersion(path, v)
+	if err != nil {
+		return nil, err
+	}
+	r := newRevision(key, v, data, vm)
+	return &r, nil
+}
+
+// Rollback writes a new version with the value of key in a version of the secret, other keys are unchanged
+func (c *Client) Rollback(key, revision string, labels map[string]string) (map[string]interface{}, error) {
+	r, err := c.GetRevision(key, revision, labels)
+	if err != nil {
+		return nil, err
+	}
+	return c.update(labels, config.Precondition{}, func(data map[string]interface{}) {
+		if r.Value == nil {
+			delete(data, key)
+			return
+		}
+		data[key] = r.Value
+	})
+}
+
+// PullConfigs returns data of the latest version of the secret
+func (c *Client) PullConfigs(labels ...map[string]string) (map[string]interface{}, error) {
+	var l map[string]string
+	if len(labels) != 0 {
+		l = labels[0]
+	}
+	path, err := c.Path(l)
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := c.Read(path)
+	return data, err
+}
+
+// PullConfig returns one key of the latest version of the secret
+func (c *Client) PullConfig(key, contentType string, labels map[string]string) (interface{}, error) {
+	data, err := c.PullConfigs(labels)
+	if err != nil {
+		return nil, err
+	}
+	v, ok := data[key]
+	if !ok {
+		return nil, config.ErrKeyNotExist
+	}
+	return v, nil
+}
+
+// PushConfigs merges items into the latest version and writes a new version with check-and-set
+func (c *Client) PushConfigs(items map[string]interface{}, labels map[string]string) (map[string]interface{}, error) {
+	if len(items) == 0 {
+		return nil, errors.New("data is empty, nothing to push")
+	}
+	return c.update(labels, config.Precondition{}, func(data map[string]interface{}) {
+		for k, v := range items {
+			data[k] = v
+		}
+	})
+}
+
+// DeleteConfigsByKeys writes a new version without the keys, previous versions are kept by vault
+func (c *Client) DeleteConfigsByKeys(keys []string, labels map[string]string) (map[string]interface{}, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no key need to delete, please check keys")
+	}
+	return c.update(labels, config.Precondition{}, func(data map[string]interface{}) {
+		for _, k := range keys {
+			delete(data, k)
+		}
+	})
+}
+
+func revisions(data map[string]interface{}, version int) *config.Revisions {
+	r := config.RevisionsOf(data)
+	r.Revision = strconv.Itoa(version)
+	return r
+}
+
+// Revisions returns the version of the secret as revision of labels, and etags of values as revisions of keys
+func (c *Client) Revisions(labels map[string]string) (*config.Revisions, error) {
+	path, err := c.Path(labels)
+	if err != nil {
+		return nil, err
+	}
+	data, version, err := c.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	return revisions(data, version), nil
+}
+
+// PushConfigsIf pushes items if revisions satisfy the precondition,
+// the check is atomic because the new version is written with check-and-set of the version just checked
+func (c *Client) PushConfigsIf(items map[string]interface{}, labels map[string]string, pre config.Precondition) (map[string]interface{}, error) {
+	if len(items) == 0 {
+		return nil, errors.New("data is empty, nothing to push")
+	}
+	return c.update(labels, pre, func(data map[string]interface{}) {
+		for k, v := range items {
+			data[k] = v
+		}
+	})
+}
+
+// DeleteConfigsByKeysIf deletes keys if revisions satisfy the precondition, like PushConfigsIf
+func (c *Client) DeleteConfigsByKeysIf(keys []string, labels map[string]string, pre config.Precondition) (map[string]interface{}, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no key need to delete, please check keys")
+	}
+	return c.update(labels, pre, func(data map[string]interface{}) {
+		for _, k := range keys {
+			delete(data, k)
+		}
+	})
+}
+
+func (c *Client) update(labels map[string]string, pre config.Precondition, modify func(data map[string]interface{})) (map[string]interface{}, error) {
+	path, err := c.Path(labels)
+	if err != nil {
+		return nil, err
+	}
+	data, version, err := c.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := pre.Check(revisions(data, version)); err != nil {
+		return nil, err
+	}
+	modify(data)
+	m, err := c.Write(path, data, version)
+	if err != nil {
+		//check-and-set fails if another write happened after read
+		if current, e := c.CurrentVersion(path); e == nil && current != version {
+			return nil, &config.ConflictError{Expected: strconv.Itoa(version), Current: strconv.Itoa(current)}
+		}
+		return nil, err
+	}
+	return map[string]interface{}{"path": path, "version": m.Version}, nil
+}
+
+// Watch polls the current version of the secret,
+// after the version changed, it calls f with data of the latest version
+func (c *Client) Watch(f func(map[string]interface{}), errHandler func(err error), labels map[string]string) error {
+	path, err := c.Path(labels)
+	if err != nil {
+		return err
+	}
+	last, err := c.CurrentVersion(path)
+	if err != nil {
+		return err
+	}
+	d := c.opts.WatchDuration
+	if d <= 0 {
+		d = defaultWatchDuration
+	}
+	go func() {
+		for range util.Tick(d, c.ctx.Done()) {
+			v, err := c.CurrentVersion(path)
+			if err != nil {
+				if c.ctx.Err() != nil {
+					return
+				}
+				errHandler(err)
+				continue
+			}
+			if v == last {
+				continue
+			}
+			data, version, err := c.Read(path)
+			if err != nil {
+				errHandler(err)
+				continue
+			}
+			last = version
+			f(data)
+		}
+	}()
+	return nil
+}
+
+// Close stops watches and cancels requests in flight
+func (c *Client) Close() error {
+	c.cancel()
+	return nil
+}
+
+// Options return settings
+func (c *Client) Options() config.Options {
+	return c.opts
+}
+
+func init() {
+	config.InstallConfigClientPlugin(Name, NewClient, config.CapabilityRead, config.CapabilityWrite, config.CapabilityWatch, config.CapabilityHistory)
+}
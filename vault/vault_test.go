@@ -0,0 +1,255 @@
+package vault_test
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/go-chassis/go-chassis-config/vault"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeVault is a in memory kv v2 engine mounted at secret
+type fakeVault struct {
+	sync.Mutex
+	secrets map[string][]map[string]interface{}
+}
+
+func (s *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+	switch {
+	case r.URL.Path == "/v1/auth/approle/login":
+		body := make(map[string]string)
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["role_id"] != "role" || body["secret_id"] != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"auth":{"client_token":"root"}}`))
+		return
+	case r.URL.Path == "/v1/auth/kubernetes/login":
+		body := make(map[string]string)
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["role"] != "app" || body["jwt"] != "jwt" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"auth":{"client_token":"root"}}`))
+		return
+	}
+	if r.Header.Get("X-Vault-Token") != "root" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/v1/secret/metadata/"):
+		versions, ok := s.secrets[strings.TrimPrefix(r.URL.Path, "/v1/secret/metadata/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		metadata := make(map[string]interface{}, len(versions))
+		for i := range versions {
+			metadata[strconv.Itoa(i+1)] = map[string]interface{}{"created_time": "2020-01-01T00:00:00Z"}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+			"current_version": len(versions),
+			"versions":        metadata,
+		}})
+	case strings.HasPrefix(r.URL.Path, "/v1/secret/data/"):
+		path := strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")
+		versions := s.secrets[path]
+		if r.Method == http.MethodGet {
+			version := len(versions)
+			if v := r.URL.Query().Get("version"); v != "" {
+				version, _ = strconv.Atoi(v)
+			}
+			if version <= 0 || version > len(versions) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+				"data":     versions[version-1],
+				"metadata": map[string]interface{}{"version": version, "created_time": "2020-01-01T00:00:00Z"},
+			}})
+			return
+		}
+		req := &vault.WriteRequest{}
+		json.NewDecoder(r.Body).Decode(req)
+		if int(req.Options["cas"].(float64)) != len(versions) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.secrets[path] = append(versions, req.Data)
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"version": len(versions) + 1}})
+	}
+}
+
+func TestClient(t *testing.T) {
+	ts := httptest.NewServer(&fakeVault{secrets: make(map[string][]map[string]interface{})})
+	defer ts.Close()
+
+	c, err := config.NewClient(vault.Name, config.Options{
+		ServerURI:     ts.URL,
+		WatchDuration: 50 * time.Millisecond,
+		Labels:        map[string]string{config.LabelApp: "mall", config.LabelService: "cart"},
+		Params:        map[string]string{vault.ParamToken: "root"},
+	})
+	assert.NoError(t, err)
+	defer c.(io.Closer).Close()
+	path, err := c.(*vault.Client).Path(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "mall/cart", path)
+
+	m, err := c.PullConfigs()
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+
+	events := make(chan map[string]interface{}, 10)
+	err = c.Watch(func(m map[string]interface{}) {
+		events <- m
+	}, func(err error) {
+		t.Log(err)
+	}, nil)
+	assert.NoError(t, err)
+
+	r, err := c.PushConfigs(map[string]interface{}{"db.password": "123", "db.user": "root"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, r["version"])
+	r, err = c.DeleteConfigsByKeys([]string{"db.user"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, r["version"])
+
+	v, err := c.PullConfig("db.password", "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "123", v)
+	_, err = c.PullConfig("db.user", "", nil)
+	assert.Equal(t, config.ErrKeyNotExist, err)
+
+	select {
+	case m := <-events:
+		assert.Equal(t, "123", m["db.password"])
+	case <-time.After(3 * time.Second):
+		t.Fatal("no event received")
+	}
+
+	_, err = c.(*vault.Client).Write(path, map[string]interface{}{}, 1)
+	assert.Error(t, err)
+}
+
+func TestClient_History(t *testing.T) {
+	ts := httptest.NewServer(&fakeVault{secrets: map[string][]map[string]interface{}{
+		"mall/cart": {
+			{"other": "1"},
+			{"other": "1", "timeout": "1s"},
+			{"other": "2", "timeout": "1s"},
+			{"other": "2", "timeout": "3s"},
+			{"other": "2"},
+		},
+	}})
+	defer ts.Close()
+	c, err := config.NewClient(vault.Name, config.Options{
+		ServerURI: ts.URL,
+		Labels:    map[string]string{config.LabelApp: "mall", config.LabelService: "cart"},
+		Params:    map[string]string{vault.ParamToken: "root"},
+	})
+	assert.NoError(t, err)
+	defer c.(io.Closer).Close()
+
+	h, ok := config.AsHistorian(c)
+	assert.True(t, ok)
+	revisions, err := h.History("timeout", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(revisions))
+	assert.Equal(t, "5", revisions[0].Revision)
+	assert.Nil(t, revisions[0].Value)
+	assert.Equal(t, "3s", revisions[1].Value)
+	assert.Equal(t, "2", revisions[2].Revision)
+	assert.Equal(t, 2020, revisions[2].Timestamp.Year())
+
+	r, err := config.GetRevision(c, "timeout", "3", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "1s", r.Value)
+	_, err = config.GetRevision(c, "timeout", "9", nil)
+	assert.Equal(t, config.ErrRevisionNotExist, err)
+
+	result, err := config.Rollback(c, "timeout", "4", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, result["version"])
+	kv, err := c.PullConfigs()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"other": "2", "timeout": "3s"}, kv)
+}
+
+func TestClient_PushConfigsIf(t *testing.T) {
+	ts := httptest.NewServer(&fakeVault{secrets: make(map[string][]map[string]interface{})})
+	defer ts.Close()
+	c, err := config.NewClient(vault.Name, config.Options{
+		ServerURI: ts.URL,
+		Labels:    map[string]string{vault.LabelPath: "mall/cart"},
+		Params:    map[string]string{vault.ParamToken: "root"},
+	})
+	assert.NoError(t, err)
+	defer c.(io.Closer).Close()
+
+	_, err = config.PushConfigsIf(c, map[string]interface{}{"timeout": "1s"}, nil, config.Precondition{Revision: "0"})
+	assert.NoError(t, err)
+	r, err := config.GetRevisions(c, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", r.Revision)
+	assert.Equal(t, config.ETag("1s"), r.Keys["timeout"])
+
+	_, err = config.PushConfigsIf(c, map[string]interface{}{"timeout": "2s"}, nil, config.Precondition{Revision: "0"})
+	assert.Equal(t, &config.ConflictError{Expected: "0", Current: "1"}, err)
+	_, err = config.DeleteConfigsByKeysIf(c, []string{"timeout"}, nil,
+		config.Precondition{Keys: map[string]string{"timeout": config.ETag("1s")}})
+	assert.NoError(t, err)
+}
+
+func TestAuth(t *testing.T) {
+	ts := httptest.NewServer(&fakeVault{secrets: map[string][]map[string]interface{}{
+		"mall": {{"a": "b"}},
+	}})
+	defer ts.Close()
+
+	c, err := vault.NewClient(config.Options{
+		ServerURI: ts.URL,
+		Labels:    map[string]string{vault.LabelPath: "mall"},
+		Params:    map[string]string{vault.ParamAuth: vault.AuthAppRole, vault.ParamRoleID: "role", vault.ParamSecretID: "secret"},
+	})
+	assert.NoError(t, err)
+	m, err := c.PullConfigs()
+	assert.NoError(t, err)
+	assert.Equal(t, "b", m["a"])
+
+	dir, err := ioutil.TempDir("", "vault")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	jwt := filepath.Join(dir, "token")
+	assert.NoError(t, ioutil.WriteFile(jwt, []byte("jwt\n"), 0600))
+	c, err = vault.NewClient(config.Options{
+		ServerURI: ts.URL,
+		Labels:    map[string]string{vault.LabelPath: "mall"},
+		Params:    map[string]string{vault.ParamAuth: vault.AuthKubernetes, vault.ParamRole: "app", vault.ParamJWTPath: jwt},
+	})
+	assert.NoError(t, err)
+	m, err = c.PullConfigs()
+	assert.NoError(t, err)
+	assert.Equal(t, "b", m["a"])
+
+	c, err = vault.NewClient(config.Options{ServerURI: ts.URL, Labels: map[string]string{vault.LabelPath: "mall"}})
+	assert.NoError(t, err)
+	_, err = c.PullConfigs()
+	assert.Equal(t, vault.ErrTokenEmpty, err)
+}
@@ -0,0 +1,511 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package zookeeper is the config client plugin of apache zookeeper,
+// it is compatible with the layout of dubbo config center
+package zookeeper
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/go-chassis/go-chassis-config/pkg/util"
+	"github.com/go-chassis/go-chassis-config/serializers"
+	"github.com/go-mesh/openlogging"
+	"github.com/go-zookeeper/zk"
+)
+
+const (
+	// Name of the Plugin
+	Name = "zookeeper"
+	//LabelPath is the label of znode path, if it is absent,
+	//path is joined by root, app, environment, serviceName and version labels
+	LabelPath = "path"
+	//ParamRoot is the root znode of configs, default is /dubbo/config
+	ParamRoot = "root"
+	//ParamSessionTimeout is the session timeout, like 30s, default is 15s
+	ParamSessionTimeout = "sessionTimeout"
+
+	defaultRoot           = "/dubbo/config"
+	defaultSessionTimeout = 15 * time.Second
+	retryInterval         = 3 * time.Second
+)
+
+// errors
+var (
+	ErrInvalidEP = errors.New("invalid endpoint")
+	ErrPathEmpty = errors.New("znode path can not be empty")
+)
+
+// Conn is the zookeeper operations used by client, *zk.Conn implements it
+type Conn interface {
+	Children(path string) ([]string, *zk.Stat, error)
+	ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event, error)
+	Get(path string) ([]byte, *zk.Stat, error)
+	GetW(path string) ([]byte, *zk.Stat, <-chan zk.Event, error)
+	Exists(path string) (bool, *zk.Stat, error)
+	ExistsW(path string) (bool, *zk.Stat, <-chan zk.Event, error)
+	Set(path string, data []byte, version int32) (*zk.Stat, error)
+	Create(path string, data []byte, flags int32, acl []zk.ACL) (string, error)
+	Delete(path string, version int32) error
+	Close()
+}
+
+// Client is zookeeper config client implementation
+type Client struct {
+	opts config.Options
+	conn Conn
+
+	mu          sync.RWMutex
+	errHandlers []func(err error)
+	done        chan struct{}
+	once        sync.Once
+}
+
+// NewClient connects to zookeeper and create zookeeper config client
+func NewClient(options config.Options) (config.Client, error) {
+	servers := make([]string, 0)
+	for _, s := range strings.Split(options.ServerURI, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			servers = append(servers, s)
+		}
+	}
+	if len(servers) == 0 {
+		return nil, ErrInvalidEP
+	}
+	timeout := defaultSessionTimeout
+	if s := options.Params[ParamSessionTimeout]; s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, err
+		}
+		timeout = d
+	}
+	conn, events, err := zk.Connect(servers, timeout)
+	if err != nil {
+		return nil, err
+	}
+	openlogging.Info("new zookeeper client", openlogging.WithTags(
+		openlogging.Tags{
+			"ep":      servers,
+			"timeout": timeout.String(),
+		}))
+	return New(conn, events, options), nil
+}
+
+// New create zookeeper config client with a connection and its session events
+func New(conn Conn, events <-chan zk.Event, options config.Options) *Client {
+	c := &Client{
+		opts: options,
+		conn: conn,
+		done: make(chan struct{}),
+	}
+	go c.session(events)
+	return c
+}
+
+// session surfaces session expiry to error handlers of watches
+func (c *Client) session(events <-chan zk.Event) {
+	for e := range events {
+		if e.State != zk.StateExpired {
+			continue
+		}
+		openlogging.GetLogger().Warn("zookeeper session expired")
+		c.mu.RLock()
+		handlers := c.errHandlers
+		c.mu.RUnlock()
+		for _, h := range handlers {
+			h(zk.ErrSessionExpired)
+		}
+	}
+}
+
+// Path returns the znode path of labels
+func (c *Client) Path(labels map[string]string) (string, error) {
+	if len(labels) == 0 {
+		labels = c.opts.Labels
+	}
+	if p := labels[LabelPath]; p != "" {
+		return path.Clean("/" + p), nil
+	}
+	root := c.opts.Params[ParamRoot]
+	if root == "" {
+		root = defaultRoot
+	}
+	elements := []string{root}
+	for _, l := range []string{config.LabelApp, config.LabelEnvironment, config.LabelService, config.LabelVersion} {
+		if labels[l] != "" {
+			elements = append(elements, labels[l])
+		}
+	}
+	if len(elements) == 1 {
+		return "", ErrPathEmpty
+	}
+	return path.Clean("/" + path.Join(elements...)), nil
+}
+
+// read returns data of children of a znode, a missing znode has no config
+func (c *Client) read(p string) (map[string]interface{}, error) {
+	kv := make(map[string]interface{})
+	children, _, err := c.conn.Children(p)
+	if err == zk.ErrNoNode {
+		return kv, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
+		data, _, err := c.conn.Get(path.Join(p, child))
+		if err == zk.ErrNoNode {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		kv[child] = string(data)
+	}
+	return kv, nil
+}
+
+// PullConfigs returns children of the znode as configs
+func (c *Client) PullConfigs(labels ...map[string]string) (map[string]interface{}, error) {
+	var l map[string]string
+	if len(labels) != 0 {
+		l = labels[0]
+	}
+	p, err := c.Path(l)
+	if err != nil {
+		return nil, err
+	}
+	return c.read(p)
+}
+
+// ListKeys returns children of the znode
+func (c *Client) ListKeys(labels map[string]string) ([]string, error) {
+	p, err := c.Path(labels)
+	if err != nil {
+		return nil, err
+	}
+	children, _, err := c.conn.Children(p)
+	if err == zk.ErrNoNode {
+		return []string{}, nil
+	}
+	return children, err
+}
+
+// PullConfig returns data of one child
+func (c *Client) PullConfig(key, contentType string, labels map[string]string) (interface{}, error) {
+	p, err := c.Path(labels)
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := c.conn.Get(path.Join(p, key))
+	if err == zk.ErrNoNode {
+		return nil, config.ErrKeyNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func toBytes(v interface{}) ([]byte, error) {
+	switch value := v.(type) {
+	case string:
+		return []byte(value), nil
+	case []byte:
+		return value, nil
+	}
+	return serializers.Encode(serializers.JsonEncoder, v)
+}
+
+// create creates a znode and its missing parents
+func (c *Client) create(p string, data []byte) error {
+	parent := path.Dir(p)
+	if parent != "/" {
+		exist, _, err := c.conn.Exists(parent)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			if err := c.create(parent, nil); err != nil && err != zk.ErrNodeExists {
+				return err
+			}
+		}
+	}
+	_, err := c.conn.Create(p, data, 0, zk.WorldACL(zk.PermAll))
+	return err
+}
+
+// PushConfigs writes each item to a child, existing children are set with the version just read,
+// so a concurrent modification fails with zk.ErrBadVersion. it returns the new version of each key
+func (c *Client) PushConfigs(items map[string]interface{}, labels map[string]string) (map[string]interface{}, error) {
+	if len(items) == 0 {
+		return nil, errors.New("data is empty, nothing to push")
+	}
+	p, err := c.Path(labels)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]interface{}, len(items))
+	for k, v := range items {
+		data, err := toBytes(v)
+		if err != nil {
+			return result, err
+		}
+		child := path.Join(p, k)
+		exist, stat, err := c.conn.Exists(child)
+		if err != nil {
+			return result, err
+		}
+		if !exist {
+			if err := c.create(child, data); err != nil {
+				return result, fmt.Errorf("create %s failed: %s", child, err)
+			}
+			result[k] = int32(0)
+			continue
+		}
+		stat, err = c.conn.Set(child, data, stat.Version)
+		if err != nil {
+			return result, fmt.Errorf("set %s failed: %s", child, err)
+		}
+		result[k] = stat.Version
+	}
+	return result, nil
+}
+
+// DeleteConfigsByKeys deletes children with the version just read
+func (c *Client) DeleteConfigsByKeys(keys []string, labels map[string]string) (map[string]interface{}, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no key need to delete, please check keys")
+	}
+	p, err := c.Path(labels)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]interface{}, len(keys))
+	for _, k := range keys {
+		child := path.Join(p, k)
+		exist, stat, err := c.conn.Exists(child)
+		if err != nil {
+			return result, err
+		}
+		if exist {
+			if err := c.conn.Delete(child, stat.Version); err != nil {
+				return result, fmt.Errorf("delete %s failed: %s", child, err)
+			}
+		}
+		result[k] = exist
+	}
+	return result, nil
+}
+
+// Watch sets zookeeper watches on the znode and its children, only the watch which fired is set again.
+// f is called with the latest configs when they changed,
+// session expiry is reported to errHandler as zk.ErrSessionExpired
+func (c *Client) Watch(f func(map[string]interface{}), errHandler func(err error), labels map[string]string) error {
+	p, err := c.Path(labels)
+	if err != nil {
+		return err
+	}
+	w := &watcher{c: c, p: p, events: make(chan watchEvent)}
+	if err := w.reset(); err != nil {
+		return err
+	}
+	last := w.snapshot()
+	c.mu.Lock()
+	c.errHandlers = append(c.errHandlers, errHandler)
+	c.mu.Unlock()
+	go func() {
+		for {
+			var e watchEvent
+			select {
+			case <-c.done:
+				return
+			case e = <-w.events:
+			}
+			if e.gen != w.gen {
+				//fired by a watch set before reset
+				continue
+			}
+			var err error
+			switch {
+			case e.Type == zk.EventNotWatching:
+				//connection closed or session expired, wait for reconnecting
+				if !util.Sleep(retryInterval, c.done) {
+					return
+				}
+				err = w.reset()
+			case e.parent:
+				err = w.watchChildren()
+			case w.children[e.child]:
+				err = w.watchChild(e.child)
+			}
+			for err != nil {
+				errHandler(err)
+				if !util.Sleep(retryInterval, c.done) {
+					return
+				}
+				err = w.reset()
+			}
+			if reflect.DeepEqual(w.kv, last) {
+				continue
+			}
+			last = w.snapshot()
+			f(w.snapshot())
+		}
+	}()
+	return nil
+}
+
+// watchEvent is an event of a zookeeper watch set by watcher
+type watchEvent struct {
+	zk.Event
+	//gen is the generation of watcher when the watch was set
+	gen int
+	//parent is true for the watch of znode, otherwise child is the name of the watched child
+	parent bool
+	child  string
+}
+
+// watcher keeps one watch on a znode and one on each of its children,
+// every zookeeper watch fires once, so that it is set again only after it fired
+type watcher struct {
+	c      *Client
+	p      string
+	gen    int
+	events chan watchEvent
+	//children are the children with a data watch
+	children map[string]bool
+	kv       map[string]interface{}
+}
+
+// forward sends the event of a watch to the watcher, the event is dropped after client is closed
+func (w *watcher) forward(ch <-chan zk.Event, parent bool, child string) {
+	gen := w.gen
+	go func() {
+		e, ok := <-ch
+		if !ok {
+			return
+		}
+		select {
+		case w.events <- watchEvent{Event: e, gen: gen, parent: parent, child: child}:
+		case <-w.c.done:
+		}
+	}()
+}
+
+// reset sets all watches again, events of previous watches are ignored
+func (w *watcher) reset() error {
+	w.gen++
+	w.children = make(map[string]bool)
+	w.kv = make(map[string]interface{})
+	return w.watchChildren()
+}
+
+// watchChildren sets the watch of znode, and data watches of new children
+func (w *watcher) watchChildren() error {
+	for {
+		children, _, ch, err := w.c.conn.ChildrenW(w.p)
+		if err == zk.ErrNoNode {
+			//wait for creation of the znode
+			exist, _, ch, err := w.c.conn.ExistsW(w.p)
+			if err != nil {
+				return err
+			}
+			if exist {
+				//created just now, the exist watch is left unused and watch its children instead
+				continue
+			}
+			w.forward(ch, true, "")
+			w.children = make(map[string]bool)
+			w.kv = make(map[string]interface{})
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		w.forward(ch, true, "")
+		current := make(map[string]bool, len(children))
+		for _, child := range children {
+			current[child] = true
+			if w.children[child] {
+				continue
+			}
+			if err := w.watchChild(child); err != nil {
+				return err
+			}
+		}
+		for child := range w.children {
+			if !current[child] {
+				//its watch fires with the deletion
+				delete(w.children, child)
+				delete(w.kv, child)
+			}
+		}
+		return nil
+	}
+}
+
+// watchChild reads a child and sets its data watch
+func (w *watcher) watchChild(child string) error {
+	data, _, ch, err := w.c.conn.GetW(path.Join(w.p, child))
+	if err == zk.ErrNoNode {
+		delete(w.children, child)
+		delete(w.kv, child)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	w.forward(ch, false, child)
+	w.children[child] = true
+	w.kv[child] = string(data)
+	return nil
+}
+
+func (w *watcher) snapshot() map[string]interface{} {
+	kv := make(map[string]interface{}, len(w.kv))
+	for k, v := range w.kv {
+		kv[k] = v
+	}
+	return kv
+}
+
+// Close stops watches and closes the connection
+func (c *Client) Close() error {
+	c.once.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+	return nil
+}
+
+// Options return settings
+func (c *Client) Options() config.Options {
+	return c.opts
+}
+
+func init() {
+	config.InstallConfigClientPlugin(Name, NewClient, config.CapabilityRead, config.CapabilityWrite, config.CapabilityWatch, config.CapabilityList)
+}
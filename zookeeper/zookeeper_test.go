@@ -0,0 +1,286 @@
+package zookeeper_test
+
+import (
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-chassis-config"
+	"github.com/go-chassis/go-chassis-config/zookeeper"
+	"github.com/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConn is a in memory znode tree
+type fakeConn struct {
+	sync.Mutex
+	nodes    map[string]*zk.Stat
+	data     map[string][]byte
+	watchers map[string][]chan zk.Event
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{
+		nodes:    map[string]*zk.Stat{"/": {}},
+		data:     make(map[string][]byte),
+		watchers: make(map[string][]chan zk.Event),
+	}
+}
+
+func (f *fakeConn) watch(p string) <-chan zk.Event {
+	ch := make(chan zk.Event, 1)
+	f.watchers[p] = append(f.watchers[p], ch)
+	return ch
+}
+
+// armed returns the number of watches not fired yet
+func (f *fakeConn) armed() int {
+	f.Lock()
+	defer f.Unlock()
+	n := 0
+	for _, chs := range f.watchers {
+		n += len(chs)
+	}
+	return n
+}
+
+func (f *fakeConn) fire(p string, t zk.EventType) {
+	for _, ch := range f.watchers[p] {
+		ch <- zk.Event{Type: t, Path: p}
+		close(ch)
+	}
+	delete(f.watchers, p)
+}
+
+func (f *fakeConn) children(p string) []string {
+	result := make([]string, 0)
+	for n := range f.nodes {
+		if n != p && path.Dir(n) == p {
+			result = append(result, path.Base(n))
+		}
+	}
+	return result
+}
+
+func (f *fakeConn) Children(p string) ([]string, *zk.Stat, error) {
+	f.Lock()
+	defer f.Unlock()
+	s, ok := f.nodes[p]
+	if !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+	return f.children(p), s, nil
+}
+
+func (f *fakeConn) ChildrenW(p string) ([]string, *zk.Stat, <-chan zk.Event, error) {
+	f.Lock()
+	defer f.Unlock()
+	s, ok := f.nodes[p]
+	if !ok {
+		return nil, nil, nil, zk.ErrNoNode
+	}
+	return f.children(p), s, f.watch(p + "#children"), nil
+}
+
+func (f *fakeConn) Get(p string) ([]byte, *zk.Stat, error) {
+	f.Lock()
+	defer f.Unlock()
+	s, ok := f.nodes[p]
+	if !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+	return f.data[p], s, nil
+}
+
+func (f *fakeConn) GetW(p string) ([]byte, *zk.Stat, <-chan zk.Event, error) {
+	f.Lock()
+	defer f.Unlock()
+	s, ok := f.nodes[p]
+	if !ok {
+		return nil, nil, nil, zk.ErrNoNode
+	}
+	return f.data[p], s, f.watch(p), nil
+}
+
+func (f *fakeConn) Exists(p string) (bool, *zk.Stat, error) {
+	f.Lock()
+	defer f.Unlock()
+	s, ok := f.nodes[p]
+	return ok, s, nil
+}
+
+func (f *fakeConn) ExistsW(p string) (bool, *zk.Stat, <-chan zk.Event, error) {
+	f.Lock()
+	defer f.Unlock()
+	s, ok := f.nodes[p]
+	return ok, s, f.watch(p), nil
+}
+
+func (f *fakeConn) Set(p string, data []byte, version int32) (*zk.Stat, error) {
+	f.Lock()
+	defer f.Unlock()
+	s, ok := f.nodes[p]
+	if !ok {
+		return nil, zk.ErrNoNode
+	}
+	if version != -1 && version != s.Version {
+		return nil, zk.ErrBadVersion
+	}
+	s = &zk.Stat{Version: s.Version + 1}
+	f.nodes[p] = s
+	f.data[p] = data
+	f.fire(p, zk.EventNodeDataChanged)
+	return s, nil
+}
+
+func (f *fakeConn) Create(p string, data []byte, flags int32, acl []zk.ACL) (string, error) {
+	f.Lock()
+	defer f.Unlock()
+	if _, ok := f.nodes[p]; ok {
+		return "", zk.ErrNodeExists
+	}
+	if _, ok := f.nodes[path.Dir(p)]; !ok {
+		return "", zk.ErrNoNode
+	}
+	f.nodes[p] = &zk.Stat{}
+	f.data[p] = data
+	f.fire(p, zk.EventNodeCreated)
+	f.fire(path.Dir(p)+"#children", zk.EventNodeChildrenChanged)
+	return p, nil
+}
+
+func (f *fakeConn) Delete(p string, version int32) error {
+	f.Lock()
+	defer f.Unlock()
+	s, ok := f.nodes[p]
+	if !ok {
+		return zk.ErrNoNode
+	}
+	if version != -1 && version != s.Version {
+		return zk.ErrBadVersion
+	}
+	delete(f.nodes, p)
+	delete(f.data, p)
+	f.fire(p, zk.EventNodeDeleted)
+	f.fire(path.Dir(p)+"#children", zk.EventNodeChildrenChanged)
+	return nil
+}
+
+func (f *fakeConn) Close() {}
+
+func TestClient(t *testing.T) {
+	conn := newFakeConn()
+	session := make(chan zk.Event, 1)
+	c := zookeeper.New(conn, session, config.Options{
+		Labels: map[string]string{config.LabelApp: "mall"},
+	})
+	defer c.Close()
+	p, err := c.Path(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "/dubbo/config/mall", p)
+
+	m, err := c.PullConfigs()
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+
+	events := make(chan map[string]interface{}, 10)
+	errs := make(chan error, 10)
+	err = c.Watch(func(m map[string]interface{}) {
+		events <- m
+	}, func(err error) {
+		errs <- err
+	}, nil)
+	assert.NoError(t, err)
+
+	r, err := c.PushConfigs(map[string]interface{}{"dubbo.properties": "timeout=1000"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), r["dubbo.properties"])
+	select {
+	case m := <-events:
+		assert.Equal(t, "timeout=1000", m["dubbo.properties"])
+	case <-time.After(3 * time.Second):
+		t.Fatal("no event received")
+	}
+
+	r, err = c.PushConfigs(map[string]interface{}{"dubbo.properties": "timeout=2000"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), r["dubbo.properties"])
+	select {
+	case m := <-events:
+		assert.Equal(t, "timeout=2000", m["dubbo.properties"])
+	case <-time.After(3 * time.Second):
+		t.Fatal("watch is not set again")
+	}
+
+	v, err := c.PullConfig("dubbo.properties", "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "timeout=2000", v)
+
+	_, err = c.DeleteConfigsByKeys([]string{"dubbo.properties"}, nil)
+	assert.NoError(t, err)
+	select {
+	case m := <-events:
+		assert.Empty(t, m)
+	case <-time.After(3 * time.Second):
+		t.Fatal("no event received")
+	}
+	_, err = c.PullConfig("dubbo.properties", "", nil)
+	assert.Equal(t, config.ErrKeyNotExist, err)
+
+	session <- zk.Event{Type: zk.EventSession, State: zk.StateExpired}
+	select {
+	case err := <-errs:
+		assert.Equal(t, zk.ErrSessionExpired, err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("session expiry is not reported")
+	}
+}
+
+func TestClient_WatchOnlyFired(t *testing.T) {
+	conn := newFakeConn()
+	c := zookeeper.New(conn, make(chan zk.Event), config.Options{
+		Labels: map[string]string{config.LabelApp: "mall"},
+	})
+	defer c.Close()
+	_, err := c.PushConfigs(map[string]interface{}{"a": "1", "b": "1", "c": "1"}, nil)
+	assert.NoError(t, err)
+
+	events := make(chan map[string]interface{}, 10)
+	err = c.Watch(func(m map[string]interface{}) {
+		events <- m
+	}, func(err error) {}, nil)
+	assert.NoError(t, err)
+	//one children watch and one data watch per child
+	assert.Equal(t, 4, conn.armed())
+
+	for _, v := range []string{"2", "3", "4"} {
+		_, err = c.PushConfigs(map[string]interface{}{"a": v}, nil)
+		assert.NoError(t, err)
+		select {
+		case m := <-events:
+			assert.Equal(t, map[string]interface{}{"a": v, "b": "1", "c": "1"}, m)
+		case <-time.After(3 * time.Second):
+			t.Fatal("no event received")
+		}
+		assert.Equal(t, 4, conn.armed())
+	}
+
+	_, err = c.DeleteConfigsByKeys([]string{"b"}, nil)
+	assert.NoError(t, err)
+	for {
+		select {
+		case m := <-events:
+			if len(m) != 2 {
+				continue
+			}
+			assert.Equal(t, map[string]interface{}{"a": "4", "c": "1"}, m)
+		case <-time.After(3 * time.Second):
+			t.Fatal("no event received")
+		}
+		break
+	}
+	//the parent watch may be set again after the event
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 3, conn.armed())
+}